@@ -0,0 +1,33 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// APIError is returned when Bittrex answers with an unexpected HTTP status.
+// Code, Detail and Data are filled from the v3 error body when present.
+type APIError struct {
+	StatusCode int             `json:"-"`
+	Status     string          `json:"-"`
+	Code       string          `json:"code"`
+	Detail     string          `json:"detail"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return e.Status
+	}
+
+	return e.Status + ": " + e.Code
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{}
+	_ = json.Unmarshal(body, e)
+	e.StatusCode = resp.StatusCode
+	e.Status = resp.Status
+
+	return e
+}
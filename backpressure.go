@@ -0,0 +1,256 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what a subscription does when its channel is full
+type OverflowPolicy int
+
+const (
+	// DropNewest drops the message which does not fit in the channel
+	DropNewest OverflowPolicy = iota
+	// Block waits for the consumer. It holds the connection read loop and
+	// long waits end in a heartbeat timeout.
+	Block
+	// DropOldest queues messages in a ring buffer of BufferSize in front of
+	// the channel and drops the oldest queued message when it is full
+	DropOldest
+	// Disconnect ends the subscription with ErrSlowConsumer so the consumer
+	// can resync
+	Disconnect
+)
+
+// DefaultBufferSize is the DropOldest ring buffer size when none is configured
+const DefaultBufferSize = 1024
+
+// ErrSlowConsumer is returned by a subscription using Disconnect when its
+// channel was full
+var ErrSlowConsumer = errors.New("slow consumer")
+
+// Backpressure configures the overflow policy of a subscription
+type Backpressure struct {
+	Policy OverflowPolicy
+	// BufferSize is the DropOldest ring buffer size
+	BufferSize int
+	// OnDrop is called with the subscription name and the number of messages
+	// dropped so far every time a message is dropped
+	OnDrop func(name string, dropped uint64)
+}
+
+// SetBackpressure sets the overflow policy of the streams created after the
+// call, including those of the typed Subscribe methods of b. The default is
+// DropNewest.
+func (b *Bittrex) SetBackpressure(bp Backpressure) {
+	b.backpressure = bp
+}
+
+// Dropped returns the number of messages the typed subscriptions of b
+// dropped so far
+func (b *Bittrex) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// Dropped returns the number of messages the typed subscriptions of s
+// dropped so far
+func (s *Stream) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// sink delivers subscription messages to a consumer channel of any type
+// according to the Backpressure of its stream.
+type sink struct {
+	stream *Stream
+	ctx    context.Context
+	name   string
+	bp     Backpressure
+	ch     reflect.Value
+
+	dropped uint64
+	failed  chan error
+	stop    chan struct{}
+	once    sync.Once
+
+	mu     sync.Mutex
+	ring   []reflect.Value
+	queued chan struct{}
+
+	// held are the messages pushed between hold and release
+	holding bool
+	held    []reflect.Value
+}
+
+// newSink returns a sink delivering the messages of the hub method name to ch
+func (stream *Stream) newSink(ctx context.Context, name string, ch interface{}) *sink {
+	s := &sink{
+		stream: stream,
+		ctx:    ctx,
+		name:   name,
+		bp:     stream.Backpressure,
+		ch:     reflect.ValueOf(ch),
+		failed: make(chan error, 1),
+		stop:   make(chan struct{}),
+	}
+
+	if s.bp.Policy == DropOldest {
+		if s.bp.BufferSize <= 0 {
+			s.bp.BufferSize = DefaultBufferSize
+		}
+		s.queued = make(chan struct{}, 1)
+		go s.pump()
+	}
+
+	return s
+}
+
+// handler returns a StreamHandler decoding messages into the element type
+// of the channel and pushing them. Decoding errors are reported to the stream.
+func (s *sink) handler() StreamHandler {
+	elem := s.ch.Type().Elem()
+
+	return func(msg StreamMessage) {
+		v := reflect.New(elem)
+		if err := json.Unmarshal(msg.Data, v.Interface()); err != nil {
+			s.stream.error(fmt.Errorf("%s: %w", msg.Channel, err))
+			return
+		}
+
+		s.push(v.Elem().Interface())
+	}
+}
+
+// hold queues the pushed messages until release
+func (s *sink) hold() {
+	s.mu.Lock()
+	s.holding = true
+	s.mu.Unlock()
+}
+
+// release delivers the held messages in order, followed by the ones pushed
+// meanwhile, and stops holding
+func (s *sink) release() {
+	for {
+		s.mu.Lock()
+		held := s.held
+		s.held = nil
+		if len(held) == 0 {
+			s.holding = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+
+		for _, val := range held {
+			s.deliver(val)
+		}
+	}
+}
+
+// push delivers v, which must be assignable to the element type of the channel
+func (s *sink) push(v interface{}) {
+	val := reflect.ValueOf(v)
+
+	s.mu.Lock()
+	if s.holding {
+		s.held = append(s.held, val)
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	s.deliver(val)
+}
+
+// deliver applies the overflow policy to val
+func (s *sink) deliver(val reflect.Value) {
+	switch s.bp.Policy {
+	case Block:
+		s.send(val)
+	case DropOldest:
+		s.mu.Lock()
+		if len(s.ring) >= s.bp.BufferSize {
+			s.ring = s.ring[1:]
+			s.drop()
+		}
+		s.ring = append(s.ring, val)
+		s.mu.Unlock()
+
+		select {
+		case s.queued <- struct{}{}:
+		default:
+		}
+	case Disconnect:
+		if !s.ch.TrySend(val) {
+			s.drop()
+			select {
+			case s.failed <- ErrSlowConsumer:
+			default:
+			}
+		}
+	default:
+		if !s.ch.TrySend(val) {
+			s.drop()
+		}
+	}
+}
+
+// close stops the delivery of queued messages when the subscription ends
+func (s *sink) close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// send blocks until val is delivered, the context is cancelled or the sink is closed
+func (s *sink) send(val reflect.Value) bool {
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: s.ch, Send: val},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.stop)},
+	})
+
+	return chosen == 0
+}
+
+func (s *sink) pump() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case <-s.queued:
+		}
+
+		for {
+			s.mu.Lock()
+			if len(s.ring) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			val := s.ring[0]
+			s.ring[0] = reflect.Value{}
+			s.ring = s.ring[1:]
+			s.mu.Unlock()
+
+			if !s.send(val) {
+				return
+			}
+		}
+	}
+}
+
+func (s *sink) drop() {
+	n := atomic.AddUint64(&s.dropped, 1)
+	atomic.AddUint64(&s.stream.dropped, 1)
+	atomic.AddUint64(&s.stream.b.dropped, 1)
+	s.stream.b.metrics.StreamDropped(s.stream.label(), s.name)
+
+	if s.bp.OnDrop != nil {
+		s.bp.OnDrop(s.name, n)
+	}
+}
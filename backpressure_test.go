@@ -0,0 +1,58 @@
+package bittrex
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSinkPolicies(t *testing.T) {
+	s := New("", "").NewStream()
+
+	var dropped uint64
+	onDrop := func(name string, n uint64) { dropped = n }
+
+	ch := make(chan int, 1)
+	s.Backpressure = Backpressure{OnDrop: onDrop}
+	out := s.newSink(context.Background(), "test", ch)
+	out.push(1)
+	out.push(2)
+	if v := <-ch; v != 1 || dropped != 1 {
+		t.Errorf("drop newest: got %d dropped %d", v, dropped)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dropped = 0
+	ch = make(chan int)
+	s.Backpressure = Backpressure{Policy: DropOldest, BufferSize: 2, OnDrop: onDrop}
+	out = s.newSink(ctx, "test", ch)
+	for i := 1; i <= 4; i++ {
+		out.push(i)
+	}
+
+	for last := 0; last != 4; {
+		v := <-ch
+		if v <= last {
+			t.Fatalf("drop oldest: got %d after %d", v, last)
+		}
+		last = v
+	}
+
+	if dropped == 0 {
+		t.Error("drop oldest: nothing dropped")
+	}
+	out.close()
+
+	ch = make(chan int)
+	s.Backpressure = Backpressure{Policy: Disconnect, OnDrop: onDrop}
+	out = s.newSink(ctx, "test", ch)
+	out.push(1)
+	if err := <-out.failed; err != ErrSlowConsumer {
+		t.Errorf("disconnect: got %v", err)
+	}
+
+	if n := s.Dropped(); n < 3 || n != s.b.Dropped() {
+		t.Errorf("stream dropped %d, client dropped %d", n, s.b.Dropped())
+	}
+}
@@ -9,3 +9,10 @@ type Balance struct {
 	Available      decimal.Decimal `json:"available"`
 	UpdatedAt      *jTime          `json:"updatedAt"`
 }
+
+// BalanceUpdate is a balance stream message
+type BalanceUpdate struct {
+	AccountID string  `json:"accountId"`
+	Sequence  int     `json:"sequence"`
+	Delta     Balance `json:"delta"`
+}
@@ -3,11 +3,16 @@ package bittrex
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/alexeykaravan/go-bittrex/internal/matching"
 )
 
 const (
@@ -28,33 +33,70 @@ const (
 	ORDER = "order"
 	//TRADE const
 	TRADE = "trade"
+	//CANDLE const
+	CANDLE = "candle"
+	//MARKETSUMMARY const
+	MARKETSUMMARY = "marketSummary"
+	//MARKETSUMMARIES const
+	MARKETSUMMARIES = "marketSummaries"
+	//TICKERS const
+	TICKERS = "tickers"
+	//BALANCE const
+	BALANCE = "balance"
+	//DEPOSIT const
+	DEPOSIT = "deposit"
+	//EXECUTION const
+	EXECUTION = "execution"
+	//CONDITIONALORDER const
+	CONDITIONALORDER = "conditionalOrder"
 	//HEARTBEAT const
 	HEARTBEAT = "heartbeat"
 	//AUTHEXPIRED const
 	AUTHEXPIRED = "authenticationExpiring"
 )
 
+// ORDERBOOKDEPTHS are the supported orderbook depths
+var ORDERBOOKDEPTHS = map[int]bool{
+	1:   true,
+	25:  true,
+	500: true,
+}
+
 // New returns an instantiated bittrex struct
 func New(apiKey, apiSecret string) *Bittrex {
 	client := NewClient(apiKey, apiSecret)
-	return &Bittrex{client}
+	return newBittrex(client)
 }
 
 // NewWithCustomHTTPClient returns an instantiated bittrex struct with custom http client
 func NewWithCustomHTTPClient(apiKey, apiSecret string, httpClient *http.Client) *Bittrex {
 	client := NewClientWithCustomHTTPConfig(apiKey, apiSecret, httpClient)
-	return &Bittrex{client}
+	return newBittrex(client)
 }
 
 // NewWithCustomTimeout returns an instantiated bittrex struct with custom timeout
 func NewWithCustomTimeout(apiKey, apiSecret string, timeout time.Duration) *Bittrex {
 	client := NewClientWithCustomTimeout(apiKey, apiSecret, timeout)
-	return &Bittrex{client}
+	return newBittrex(client)
 }
 
 // Bittrex represent a Bittrex client
 type Bittrex struct {
-	client *Client
+	client       *Client
+	metrics      Metrics
+	wsBase       string
+	backpressure Backpressure
+	dropped      uint64
+
+	hooksMu          sync.RWMutex
+	streamHooks      []StreamHandler
+	streamErrorHooks []func(err error)
+}
+
+func newBittrex(client *Client) *Bittrex {
+	b := &Bittrex{client: client, metrics: nopMetrics{}}
+	client.OnResponse(b.observeResponse)
+	return b
 }
 
 // SetDebug set enable/disable http request/response dump
@@ -62,9 +104,39 @@ func (b *Bittrex) SetDebug(enable bool) {
 	b.client.debug = enable
 }
 
+// SetAPIBase points the REST client to another server, like a bittrextest.Server
+func (b *Bittrex) SetAPIBase(base string) {
+	b.client.SetAPIBase(base)
+}
+
+// SetWSBase points streams to another SignalR host, like a bittrextest.Hub.
+// The host is dialed over TLS.
+func (b *Bittrex) SetWSBase(host string) {
+	b.wsBase = host
+}
+
+// wsHost returns the SignalR host, WSBASE by default
+func (b *Bittrex) wsHost() string {
+	if b.wsBase == "" {
+		return WSBASE
+	}
+
+	return b.wsBase
+}
+
+// OnRequest registers a hook called with every signed REST request before it is sent
+func (b *Bittrex) OnRequest(h RequestHook) {
+	b.client.OnRequest(h)
+}
+
+// OnResponse registers a hook called after every REST request
+func (b *Bittrex) OnResponse(h ResponseHook) {
+	b.client.OnResponse(h)
+}
+
 // GetMarkets is used to get the open and available trading markets at Bittrex along with other meta data.
 func (b *Bittrex) GetMarkets() (markets []Market, err error) {
-	r, err := b.client.do("GET", "markets", "", false)
+	r, err := b.client.do("GET", "markets", "markets", "", false)
 	if err != nil {
 		return
 	}
@@ -75,7 +147,7 @@ func (b *Bittrex) GetMarkets() (markets []Market, err error) {
 
 // GetTicker is used to get the current ticker values for a market.
 func (b *Bittrex) GetTicker(market string) (ticker Ticker, err error) {
-	r, err := b.client.do("GET", "markets/"+strings.ToUpper(market)+"/ticker", "", false)
+	r, err := b.client.do("GET", "markets/{marketSymbol}/ticker", "markets/"+strings.ToUpper(market)+"/ticker", "", false)
 	if err != nil {
 		return
 	}
@@ -84,9 +156,69 @@ func (b *Bittrex) GetTicker(market string) (ticker Ticker, err error) {
 	return
 }
 
+// GetTickers is used to get the current ticker values of all markets.
+func (b *Bittrex) GetTickers() (tickers []Ticker, err error) {
+	r, err := b.client.do("GET", "markets/tickers", "markets/tickers", "", false)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(r, &tickers)
+	return
+}
+
+// GetMarketSummaries is used to get the last 24 hour summary of all markets.
+func (b *Bittrex) GetMarketSummaries() (summaries []MarketSummary, err error) {
+	r, err := b.client.do("GET", "markets/summaries", "markets/summaries", "", false)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(r, &summaries)
+	return
+}
+
+// GetMarketSummary is used to get the last 24 hour summary of a market.
+func (b *Bittrex) GetMarketSummary(market string) (summary MarketSummary, err error) {
+	r, err := b.client.do("GET", "markets/{marketSymbol}/summary", "markets/"+strings.ToUpper(market)+"/summary", "", false)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(r, &summary)
+	return
+}
+
+// GetTrades is used to get the most recent trades of a market.
+func (b *Bittrex) GetTrades(market string) (trades []Trade, err error) {
+	r, err := b.client.do("GET", "markets/{marketSymbol}/trades", "markets/"+strings.ToUpper(market)+"/trades", "", false)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(r, &trades)
+	return
+}
+
+// GetCandles is used to get the recent candles of a market.
+// interval is one of CANDLEINTERVALS.
+func (b *Bittrex) GetCandles(market, interval string) (candles []Candle, err error) {
+	if !CANDLEINTERVALS[interval] {
+		return nil, errors.New("wrong candle interval: " + interval)
+	}
+
+	r, err := b.client.do("GET", "markets/{marketSymbol}/candles/{candleInterval}/recent", "markets/"+strings.ToUpper(market)+"/candles/"+interval+"/recent", "", false)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(r, &candles)
+	return
+}
+
 // GetOrderBook is used to get the current orderbook values for a market.
 func (b *Bittrex) GetOrderBook(book *OrderBook) (err error) {
-	resp, err := b.client.do2("markets/" + strings.ToUpper(book.MarketSymbol) + "/orderbook?depth=" + strconv.Itoa(book.Depth))
+	resp, err := b.client.do2("markets/{marketSymbol}/orderbook", "markets/"+strings.ToUpper(book.MarketSymbol)+"/orderbook?depth="+strconv.Itoa(book.Depth))
 	if err != nil {
 		return
 	}
@@ -126,14 +258,14 @@ func (b *Bittrex) NewOrder(order NewOrder) (response []byte, err error) {
 		return
 	}
 
-	r, err := b.client.do("POST", "orders", string(data), true)
+	r, err := b.client.do("POST", "orders", "orders", string(data), true)
 
 	return r, err
 }
 
 // CancelOrder is used to cancel a buy or sell order.
 func (b *Bittrex) CancelOrder(orderID string) (respone []byte, err error) {
-	r, err := b.client.do("DELETE", "orders/"+orderID, "", true)
+	r, err := b.client.do("DELETE", "orders/{orderId}", "orders/"+orderID, "", true)
 
 	return r, err
 }
@@ -146,7 +278,7 @@ func (b *Bittrex) GetOpenOrders(market string) (openOrders []Order, err error) {
 		resource += "?marketSymbol=" + strings.ToUpper(market)
 	}
 
-	r, err := b.client.do("GET", resource, "", true)
+	r, err := b.client.do("GET", "orders/open", resource, "", true)
 	if err != nil {
 		return
 	}
@@ -160,7 +292,7 @@ func (b *Bittrex) GetOrder(orderUUID string) (order Order, err error) {
 
 	resource := "orders/" + orderUUID
 
-	r, err := b.client.do("GET", resource, "", true)
+	r, err := b.client.do("GET", "orders/{orderId}", resource, "", true)
 	if err != nil {
 		return
 	}
@@ -173,7 +305,7 @@ func (b *Bittrex) GetOrder(orderUUID string) (order Order, err error) {
 
 // GetBalances is used to retrieve all balances from your account
 func (b *Bittrex) GetBalances() (balances []Balance, err error) {
-	r, err := b.client.do("GET", "balances", "", true)
+	r, err := b.client.do("GET", "balances", "balances", "", true)
 	if err != nil {
 		return
 	}
@@ -184,6 +316,7 @@ func (b *Bittrex) GetBalances() (balances []Balance, err error) {
 
 // GetOrderHistory used to retrieve your order history.
 // market string literal for the market (ie. BTC-LTC). If set to "all", will return for all market
+// Only the newest page is returned, see GetOrderHistoryPage.
 func (b *Bittrex) GetOrderHistory(market string) (orders []Order, err error) {
 	resource := "orders/closed"
 
@@ -191,7 +324,66 @@ func (b *Bittrex) GetOrderHistory(market string) (orders []Order, err error) {
 		resource += "?marketSymbol=" + strings.ToUpper(market)
 	}
 
-	r, err := b.client.do("GET", resource, "", true)
+	r, err := b.client.do("GET", "orders/closed", resource, "", true)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(r, &orders)
+	return
+}
+
+// MaxPageSize is the largest page of a paginated history
+const MaxPageSize = matching.MaxPageSize
+
+// HistoryPage selects a page of a paginated history, newest first.
+// NextPageToken is the ID of the last item of the previous page and
+// PreviousPageToken the ID of the first item of the next page. A zero PageSize
+// returns the default page of 100 items. Zero dates are not sent.
+type HistoryPage struct {
+	NextPageToken     string
+	PreviousPageToken string
+	PageSize          int
+	StartDate         time.Time
+	EndDate           time.Time
+}
+
+func (p HistoryPage) values() url.Values {
+	v := url.Values{}
+
+	if p.NextPageToken != "" {
+		v.Set("nextPageToken", p.NextPageToken)
+	}
+	if p.PreviousPageToken != "" {
+		v.Set("previousPageToken", p.PreviousPageToken)
+	}
+	if p.PageSize > 0 {
+		v.Set("pageSize", strconv.Itoa(p.PageSize))
+	}
+	if !p.StartDate.IsZero() {
+		v.Set("startDate", p.StartDate.UTC().Format(time.RFC3339))
+	}
+	if !p.EndDate.IsZero() {
+		v.Set("endDate", p.EndDate.UTC().Format(time.RFC3339))
+	}
+
+	return v
+}
+
+// GetOrderHistoryPage returns a page of the closed orders of market, of all
+// markets when market is empty
+func (b *Bittrex) GetOrderHistoryPage(market string, page HistoryPage) (orders []Order, err error) {
+	v := page.values()
+	if market != "" {
+		v.Set("marketSymbol", strings.ToUpper(market))
+	}
+
+	resource := "orders/closed"
+	if len(v) > 0 {
+		resource += "?" + v.Encode()
+	}
+
+	r, err := b.client.do("GET", "orders/closed", resource, "", true)
 	if err != nil {
 		return
 	}
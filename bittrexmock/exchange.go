@@ -0,0 +1,989 @@
+// Package bittrexmock provides an in-memory bittrex.Exchange for tests
+package bittrexmock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alexeykaravan/go-bittrex"
+	"github.com/shopspring/decimal"
+)
+
+var _ bittrex.Exchange = (*Exchange)(nil)
+
+// Exchange is a programmable bittrex.Exchange. Every method records its
+// arguments and returns the result of the matching Func field. When the
+// field is nil REST methods return zero values and subscriptions block
+// until ctx is cancelled, like the real ones without messages.
+type Exchange struct {
+	GetMarketsFunc                       func() ([]bittrex.Market, error)
+	GetTickerFunc                        func(market string) (bittrex.Ticker, error)
+	GetTickersFunc                       func() ([]bittrex.Ticker, error)
+	GetMarketSummariesFunc               func() ([]bittrex.MarketSummary, error)
+	GetMarketSummaryFunc                 func(market string) (bittrex.MarketSummary, error)
+	GetTradesFunc                        func(market string) ([]bittrex.Trade, error)
+	GetCandlesFunc                       func(market string, interval string) ([]bittrex.Candle, error)
+	GetOrderBookFunc                     func(book *bittrex.OrderBook) error
+	NewOrderFunc                         func(order bittrex.NewOrder) ([]byte, error)
+	CancelOrderFunc                      func(orderID string) ([]byte, error)
+	ReplaceOrderFunc                     func(ctx context.Context, orderID string, newPrice decimal.Decimal, newQty decimal.Decimal) (bittrex.ReplaceResult, error)
+	WaitForOrderFunc                     func(ctx context.Context, orderID string, condition bittrex.OrderCondition) (bittrex.Order, error)
+	GetOpenOrdersFunc                    func(market string) ([]bittrex.Order, error)
+	GetOrderFunc                         func(orderID string) (bittrex.Order, error)
+	GetOrderHistoryFunc                  func(market string) ([]bittrex.Order, error)
+	GetOrderHistoryPageFunc              func(market string, page bittrex.HistoryPage) ([]bittrex.Order, error)
+	GetOrderHistoryByTagFunc             func(market string, tag string) ([]bittrex.Order, error)
+	GetClosedOrderByClientOrderIDFunc    func(market string, clientOrderID string) (bittrex.Order, error)
+	GetBalancesFunc                      func() ([]bittrex.Balance, error)
+	SubscribeTickerUpdatesFunc           func(ctx context.Context, ticker chan<- bittrex.Ticker, markets ...string) error
+	SubscribeOrderUpdatesFunc            func(ctx context.Context, dataCh chan<- bittrex.OrderUpdate) error
+	SubscribeOrderbookUpdatesFunc        func(ctx context.Context, orderbook chan<- bittrex.OrderBook, depth int, markets ...string) error
+	SubscribeTradesFunc                  func(ctx context.Context, trades chan<- bittrex.TradeUpdate, markets ...string) error
+	SubscribeCandlesFunc                 func(ctx context.Context, market string, interval string, candles chan<- bittrex.CandleUpdate, seed bool) error
+	SubscribeBalanceUpdatesFunc          func(ctx context.Context, dataCh chan<- bittrex.BalanceUpdate) error
+	SubscribeDepositUpdatesFunc          func(ctx context.Context, dataCh chan<- bittrex.DepositUpdate) error
+	SubscribeExecutionUpdatesFunc        func(ctx context.Context, dataCh chan<- bittrex.ExecutionUpdate) error
+	SubscribeConditionalOrderUpdatesFunc func(ctx context.Context, dataCh chan<- bittrex.ConditionalOrderUpdate) error
+	SubscribeMarketSummariesFunc         func(ctx context.Context, dataCh chan<- bittrex.MarketSummariesUpdate) error
+	SubscribeMarketSummaryFunc           func(ctx context.Context, dataCh chan<- bittrex.MarketSummary, markets ...string) error
+	SubscribeTickersFunc                 func(ctx context.Context, dataCh chan<- bittrex.TickersUpdate) error
+
+	mu    sync.Mutex
+	calls calls
+}
+
+// calls holds the recorded calls of each method
+type calls struct {
+	GetMarkets                       []GetMarketsCall
+	GetTicker                        []GetTickerCall
+	GetTickers                       []GetTickersCall
+	GetMarketSummaries               []GetMarketSummariesCall
+	GetMarketSummary                 []GetMarketSummaryCall
+	GetTrades                        []GetTradesCall
+	GetCandles                       []GetCandlesCall
+	GetOrderBook                     []GetOrderBookCall
+	NewOrder                         []NewOrderCall
+	CancelOrder                      []CancelOrderCall
+	ReplaceOrder                     []ReplaceOrderCall
+	WaitForOrder                     []WaitForOrderCall
+	GetOpenOrders                    []GetOpenOrdersCall
+	GetOrder                         []GetOrderCall
+	GetOrderHistory                  []GetOrderHistoryCall
+	GetOrderHistoryPage              []GetOrderHistoryPageCall
+	GetOrderHistoryByTag             []GetOrderHistoryByTagCall
+	GetClosedOrderByClientOrderID    []GetClosedOrderByClientOrderIDCall
+	GetBalances                      []GetBalancesCall
+	SubscribeTickerUpdates           []SubscribeTickerUpdatesCall
+	SubscribeOrderUpdates            []SubscribeOrderUpdatesCall
+	SubscribeOrderbookUpdates        []SubscribeOrderbookUpdatesCall
+	SubscribeTrades                  []SubscribeTradesCall
+	SubscribeCandles                 []SubscribeCandlesCall
+	SubscribeBalanceUpdates          []SubscribeBalanceUpdatesCall
+	SubscribeDepositUpdates          []SubscribeDepositUpdatesCall
+	SubscribeExecutionUpdates        []SubscribeExecutionUpdatesCall
+	SubscribeConditionalOrderUpdates []SubscribeConditionalOrderUpdatesCall
+	SubscribeMarketSummaries         []SubscribeMarketSummariesCall
+	SubscribeMarketSummary           []SubscribeMarketSummaryCall
+	SubscribeTickers                 []SubscribeTickersCall
+}
+
+// GetMarketsCall records a call of GetMarkets
+type GetMarketsCall struct {
+}
+
+// GetTickerCall records a call of GetTicker
+type GetTickerCall struct {
+	Market string
+}
+
+// GetTickersCall records a call of GetTickers
+type GetTickersCall struct {
+}
+
+// GetMarketSummariesCall records a call of GetMarketSummaries
+type GetMarketSummariesCall struct {
+}
+
+// GetMarketSummaryCall records a call of GetMarketSummary
+type GetMarketSummaryCall struct {
+	Market string
+}
+
+// GetTradesCall records a call of GetTrades
+type GetTradesCall struct {
+	Market string
+}
+
+// GetCandlesCall records a call of GetCandles
+type GetCandlesCall struct {
+	Market   string
+	Interval string
+}
+
+// GetOrderBookCall records a call of GetOrderBook
+type GetOrderBookCall struct {
+	Book *bittrex.OrderBook
+}
+
+// NewOrderCall records a call of NewOrder
+type NewOrderCall struct {
+	Order bittrex.NewOrder
+}
+
+// CancelOrderCall records a call of CancelOrder
+type CancelOrderCall struct {
+	OrderID string
+}
+
+// ReplaceOrderCall records a call of ReplaceOrder
+type ReplaceOrderCall struct {
+	Ctx      context.Context
+	OrderID  string
+	NewPrice decimal.Decimal
+	NewQty   decimal.Decimal
+}
+
+// WaitForOrderCall records a call of WaitForOrder
+type WaitForOrderCall struct {
+	Ctx       context.Context
+	OrderID   string
+	Condition bittrex.OrderCondition
+}
+
+// GetOpenOrdersCall records a call of GetOpenOrders
+type GetOpenOrdersCall struct {
+	Market string
+}
+
+// GetOrderCall records a call of GetOrder
+type GetOrderCall struct {
+	OrderID string
+}
+
+// GetOrderHistoryCall records a call of GetOrderHistory
+type GetOrderHistoryCall struct {
+	Market string
+}
+
+// GetOrderHistoryPageCall records a call of GetOrderHistoryPage
+type GetOrderHistoryPageCall struct {
+	Market string
+	Page   bittrex.HistoryPage
+}
+
+// GetOrderHistoryByTagCall records a call of GetOrderHistoryByTag
+type GetOrderHistoryByTagCall struct {
+	Market string
+	Tag    string
+}
+
+// GetClosedOrderByClientOrderIDCall records a call of GetClosedOrderByClientOrderID
+type GetClosedOrderByClientOrderIDCall struct {
+	Market        string
+	ClientOrderID string
+}
+
+// GetBalancesCall records a call of GetBalances
+type GetBalancesCall struct {
+}
+
+// SubscribeTickerUpdatesCall records a call of SubscribeTickerUpdates
+type SubscribeTickerUpdatesCall struct {
+	Ctx     context.Context
+	Ticker  chan<- bittrex.Ticker
+	Markets []string
+}
+
+// SubscribeOrderUpdatesCall records a call of SubscribeOrderUpdates
+type SubscribeOrderUpdatesCall struct {
+	Ctx    context.Context
+	DataCh chan<- bittrex.OrderUpdate
+}
+
+// SubscribeOrderbookUpdatesCall records a call of SubscribeOrderbookUpdates
+type SubscribeOrderbookUpdatesCall struct {
+	Ctx       context.Context
+	Orderbook chan<- bittrex.OrderBook
+	Depth     int
+	Markets   []string
+}
+
+// SubscribeTradesCall records a call of SubscribeTrades
+type SubscribeTradesCall struct {
+	Ctx     context.Context
+	Trades  chan<- bittrex.TradeUpdate
+	Markets []string
+}
+
+// SubscribeCandlesCall records a call of SubscribeCandles
+type SubscribeCandlesCall struct {
+	Ctx      context.Context
+	Market   string
+	Interval string
+	Candles  chan<- bittrex.CandleUpdate
+	Seed     bool
+}
+
+// SubscribeBalanceUpdatesCall records a call of SubscribeBalanceUpdates
+type SubscribeBalanceUpdatesCall struct {
+	Ctx    context.Context
+	DataCh chan<- bittrex.BalanceUpdate
+}
+
+// SubscribeDepositUpdatesCall records a call of SubscribeDepositUpdates
+type SubscribeDepositUpdatesCall struct {
+	Ctx    context.Context
+	DataCh chan<- bittrex.DepositUpdate
+}
+
+// SubscribeExecutionUpdatesCall records a call of SubscribeExecutionUpdates
+type SubscribeExecutionUpdatesCall struct {
+	Ctx    context.Context
+	DataCh chan<- bittrex.ExecutionUpdate
+}
+
+// SubscribeConditionalOrderUpdatesCall records a call of SubscribeConditionalOrderUpdates
+type SubscribeConditionalOrderUpdatesCall struct {
+	Ctx    context.Context
+	DataCh chan<- bittrex.ConditionalOrderUpdate
+}
+
+// SubscribeMarketSummariesCall records a call of SubscribeMarketSummaries
+type SubscribeMarketSummariesCall struct {
+	Ctx    context.Context
+	DataCh chan<- bittrex.MarketSummariesUpdate
+}
+
+// SubscribeMarketSummaryCall records a call of SubscribeMarketSummary
+type SubscribeMarketSummaryCall struct {
+	Ctx     context.Context
+	DataCh  chan<- bittrex.MarketSummary
+	Markets []string
+}
+
+// SubscribeTickersCall records a call of SubscribeTickers
+type SubscribeTickersCall struct {
+	Ctx    context.Context
+	DataCh chan<- bittrex.TickersUpdate
+}
+
+// GetMarkets calls GetMarketsFunc
+func (m *Exchange) GetMarkets() ([]bittrex.Market, error) {
+	m.mu.Lock()
+	m.calls.GetMarkets = append(m.calls.GetMarkets, GetMarketsCall{})
+	f := m.GetMarketsFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Market
+		return r0, nil
+	}
+
+	return f()
+}
+
+// GetMarketsCalls returns the calls of GetMarkets
+func (m *Exchange) GetMarketsCalls() []GetMarketsCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetMarketsCall(nil), m.calls.GetMarkets...)
+}
+
+// GetTicker calls GetTickerFunc
+func (m *Exchange) GetTicker(market string) (bittrex.Ticker, error) {
+	m.mu.Lock()
+	m.calls.GetTicker = append(m.calls.GetTicker, GetTickerCall{Market: market})
+	f := m.GetTickerFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 bittrex.Ticker
+		return r0, nil
+	}
+
+	return f(market)
+}
+
+// GetTickerCalls returns the calls of GetTicker
+func (m *Exchange) GetTickerCalls() []GetTickerCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetTickerCall(nil), m.calls.GetTicker...)
+}
+
+// GetTickers calls GetTickersFunc
+func (m *Exchange) GetTickers() ([]bittrex.Ticker, error) {
+	m.mu.Lock()
+	m.calls.GetTickers = append(m.calls.GetTickers, GetTickersCall{})
+	f := m.GetTickersFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Ticker
+		return r0, nil
+	}
+
+	return f()
+}
+
+// GetTickersCalls returns the calls of GetTickers
+func (m *Exchange) GetTickersCalls() []GetTickersCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetTickersCall(nil), m.calls.GetTickers...)
+}
+
+// GetMarketSummaries calls GetMarketSummariesFunc
+func (m *Exchange) GetMarketSummaries() ([]bittrex.MarketSummary, error) {
+	m.mu.Lock()
+	m.calls.GetMarketSummaries = append(m.calls.GetMarketSummaries, GetMarketSummariesCall{})
+	f := m.GetMarketSummariesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.MarketSummary
+		return r0, nil
+	}
+
+	return f()
+}
+
+// GetMarketSummariesCalls returns the calls of GetMarketSummaries
+func (m *Exchange) GetMarketSummariesCalls() []GetMarketSummariesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetMarketSummariesCall(nil), m.calls.GetMarketSummaries...)
+}
+
+// GetMarketSummary calls GetMarketSummaryFunc
+func (m *Exchange) GetMarketSummary(market string) (bittrex.MarketSummary, error) {
+	m.mu.Lock()
+	m.calls.GetMarketSummary = append(m.calls.GetMarketSummary, GetMarketSummaryCall{Market: market})
+	f := m.GetMarketSummaryFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 bittrex.MarketSummary
+		return r0, nil
+	}
+
+	return f(market)
+}
+
+// GetMarketSummaryCalls returns the calls of GetMarketSummary
+func (m *Exchange) GetMarketSummaryCalls() []GetMarketSummaryCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetMarketSummaryCall(nil), m.calls.GetMarketSummary...)
+}
+
+// GetTrades calls GetTradesFunc
+func (m *Exchange) GetTrades(market string) ([]bittrex.Trade, error) {
+	m.mu.Lock()
+	m.calls.GetTrades = append(m.calls.GetTrades, GetTradesCall{Market: market})
+	f := m.GetTradesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Trade
+		return r0, nil
+	}
+
+	return f(market)
+}
+
+// GetTradesCalls returns the calls of GetTrades
+func (m *Exchange) GetTradesCalls() []GetTradesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetTradesCall(nil), m.calls.GetTrades...)
+}
+
+// GetCandles calls GetCandlesFunc
+func (m *Exchange) GetCandles(market string, interval string) ([]bittrex.Candle, error) {
+	m.mu.Lock()
+	m.calls.GetCandles = append(m.calls.GetCandles, GetCandlesCall{Market: market, Interval: interval})
+	f := m.GetCandlesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Candle
+		return r0, nil
+	}
+
+	return f(market, interval)
+}
+
+// GetCandlesCalls returns the calls of GetCandles
+func (m *Exchange) GetCandlesCalls() []GetCandlesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetCandlesCall(nil), m.calls.GetCandles...)
+}
+
+// GetOrderBook calls GetOrderBookFunc
+func (m *Exchange) GetOrderBook(book *bittrex.OrderBook) error {
+	m.mu.Lock()
+	m.calls.GetOrderBook = append(m.calls.GetOrderBook, GetOrderBookCall{Book: book})
+	f := m.GetOrderBookFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		return nil
+	}
+
+	return f(book)
+}
+
+// GetOrderBookCalls returns the calls of GetOrderBook
+func (m *Exchange) GetOrderBookCalls() []GetOrderBookCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetOrderBookCall(nil), m.calls.GetOrderBook...)
+}
+
+// NewOrder calls NewOrderFunc
+func (m *Exchange) NewOrder(order bittrex.NewOrder) ([]byte, error) {
+	m.mu.Lock()
+	m.calls.NewOrder = append(m.calls.NewOrder, NewOrderCall{Order: order})
+	f := m.NewOrderFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []byte
+		return r0, nil
+	}
+
+	return f(order)
+}
+
+// NewOrderCalls returns the calls of NewOrder
+func (m *Exchange) NewOrderCalls() []NewOrderCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]NewOrderCall(nil), m.calls.NewOrder...)
+}
+
+// CancelOrder calls CancelOrderFunc
+func (m *Exchange) CancelOrder(orderID string) ([]byte, error) {
+	m.mu.Lock()
+	m.calls.CancelOrder = append(m.calls.CancelOrder, CancelOrderCall{OrderID: orderID})
+	f := m.CancelOrderFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []byte
+		return r0, nil
+	}
+
+	return f(orderID)
+}
+
+// CancelOrderCalls returns the calls of CancelOrder
+func (m *Exchange) CancelOrderCalls() []CancelOrderCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]CancelOrderCall(nil), m.calls.CancelOrder...)
+}
+
+// ReplaceOrder calls ReplaceOrderFunc
+func (m *Exchange) ReplaceOrder(ctx context.Context, orderID string, newPrice decimal.Decimal, newQty decimal.Decimal) (bittrex.ReplaceResult, error) {
+	m.mu.Lock()
+	m.calls.ReplaceOrder = append(m.calls.ReplaceOrder, ReplaceOrderCall{Ctx: ctx, OrderID: orderID, NewPrice: newPrice, NewQty: newQty})
+	f := m.ReplaceOrderFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 bittrex.ReplaceResult
+		return r0, nil
+	}
+
+	return f(ctx, orderID, newPrice, newQty)
+}
+
+// ReplaceOrderCalls returns the calls of ReplaceOrder
+func (m *Exchange) ReplaceOrderCalls() []ReplaceOrderCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]ReplaceOrderCall(nil), m.calls.ReplaceOrder...)
+}
+
+// WaitForOrder calls WaitForOrderFunc
+func (m *Exchange) WaitForOrder(ctx context.Context, orderID string, condition bittrex.OrderCondition) (bittrex.Order, error) {
+	m.mu.Lock()
+	m.calls.WaitForOrder = append(m.calls.WaitForOrder, WaitForOrderCall{Ctx: ctx, OrderID: orderID, Condition: condition})
+	f := m.WaitForOrderFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 bittrex.Order
+		return r0, nil
+	}
+
+	return f(ctx, orderID, condition)
+}
+
+// WaitForOrderCalls returns the calls of WaitForOrder
+func (m *Exchange) WaitForOrderCalls() []WaitForOrderCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]WaitForOrderCall(nil), m.calls.WaitForOrder...)
+}
+
+// GetOpenOrders calls GetOpenOrdersFunc
+func (m *Exchange) GetOpenOrders(market string) ([]bittrex.Order, error) {
+	m.mu.Lock()
+	m.calls.GetOpenOrders = append(m.calls.GetOpenOrders, GetOpenOrdersCall{Market: market})
+	f := m.GetOpenOrdersFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Order
+		return r0, nil
+	}
+
+	return f(market)
+}
+
+// GetOpenOrdersCalls returns the calls of GetOpenOrders
+func (m *Exchange) GetOpenOrdersCalls() []GetOpenOrdersCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetOpenOrdersCall(nil), m.calls.GetOpenOrders...)
+}
+
+// GetOrder calls GetOrderFunc
+func (m *Exchange) GetOrder(orderID string) (bittrex.Order, error) {
+	m.mu.Lock()
+	m.calls.GetOrder = append(m.calls.GetOrder, GetOrderCall{OrderID: orderID})
+	f := m.GetOrderFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 bittrex.Order
+		return r0, nil
+	}
+
+	return f(orderID)
+}
+
+// GetOrderCalls returns the calls of GetOrder
+func (m *Exchange) GetOrderCalls() []GetOrderCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetOrderCall(nil), m.calls.GetOrder...)
+}
+
+// GetOrderHistory calls GetOrderHistoryFunc
+func (m *Exchange) GetOrderHistory(market string) ([]bittrex.Order, error) {
+	m.mu.Lock()
+	m.calls.GetOrderHistory = append(m.calls.GetOrderHistory, GetOrderHistoryCall{Market: market})
+	f := m.GetOrderHistoryFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Order
+		return r0, nil
+	}
+
+	return f(market)
+}
+
+// GetOrderHistoryCalls returns the calls of GetOrderHistory
+func (m *Exchange) GetOrderHistoryCalls() []GetOrderHistoryCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetOrderHistoryCall(nil), m.calls.GetOrderHistory...)
+}
+
+// GetOrderHistoryPage calls GetOrderHistoryPageFunc
+func (m *Exchange) GetOrderHistoryPage(market string, page bittrex.HistoryPage) ([]bittrex.Order, error) {
+	m.mu.Lock()
+	m.calls.GetOrderHistoryPage = append(m.calls.GetOrderHistoryPage, GetOrderHistoryPageCall{Market: market, Page: page})
+	f := m.GetOrderHistoryPageFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Order
+		return r0, nil
+	}
+
+	return f(market, page)
+}
+
+// GetOrderHistoryPageCalls returns the calls of GetOrderHistoryPage
+func (m *Exchange) GetOrderHistoryPageCalls() []GetOrderHistoryPageCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetOrderHistoryPageCall(nil), m.calls.GetOrderHistoryPage...)
+}
+
+// GetOrderHistoryByTag calls GetOrderHistoryByTagFunc
+func (m *Exchange) GetOrderHistoryByTag(market string, tag string) ([]bittrex.Order, error) {
+	m.mu.Lock()
+	m.calls.GetOrderHistoryByTag = append(m.calls.GetOrderHistoryByTag, GetOrderHistoryByTagCall{Market: market, Tag: tag})
+	f := m.GetOrderHistoryByTagFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Order
+		return r0, nil
+	}
+
+	return f(market, tag)
+}
+
+// GetOrderHistoryByTagCalls returns the calls of GetOrderHistoryByTag
+func (m *Exchange) GetOrderHistoryByTagCalls() []GetOrderHistoryByTagCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetOrderHistoryByTagCall(nil), m.calls.GetOrderHistoryByTag...)
+}
+
+// GetClosedOrderByClientOrderID calls GetClosedOrderByClientOrderIDFunc
+func (m *Exchange) GetClosedOrderByClientOrderID(market string, clientOrderID string) (bittrex.Order, error) {
+	m.mu.Lock()
+	m.calls.GetClosedOrderByClientOrderID = append(m.calls.GetClosedOrderByClientOrderID,
+		GetClosedOrderByClientOrderIDCall{Market: market, ClientOrderID: clientOrderID})
+	f := m.GetClosedOrderByClientOrderIDFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 bittrex.Order
+		return r0, nil
+	}
+
+	return f(market, clientOrderID)
+}
+
+// GetClosedOrderByClientOrderIDCalls returns the calls of GetClosedOrderByClientOrderID
+func (m *Exchange) GetClosedOrderByClientOrderIDCalls() []GetClosedOrderByClientOrderIDCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetClosedOrderByClientOrderIDCall(nil), m.calls.GetClosedOrderByClientOrderID...)
+}
+
+// GetBalances calls GetBalancesFunc
+func (m *Exchange) GetBalances() ([]bittrex.Balance, error) {
+	m.mu.Lock()
+	m.calls.GetBalances = append(m.calls.GetBalances, GetBalancesCall{})
+	f := m.GetBalancesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		var r0 []bittrex.Balance
+		return r0, nil
+	}
+
+	return f()
+}
+
+// GetBalancesCalls returns the calls of GetBalances
+func (m *Exchange) GetBalancesCalls() []GetBalancesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]GetBalancesCall(nil), m.calls.GetBalances...)
+}
+
+// SubscribeTickerUpdates calls SubscribeTickerUpdatesFunc
+func (m *Exchange) SubscribeTickerUpdates(ctx context.Context, ticker chan<- bittrex.Ticker, markets ...string) error {
+	m.mu.Lock()
+	m.calls.SubscribeTickerUpdates = append(m.calls.SubscribeTickerUpdates, SubscribeTickerUpdatesCall{Ctx: ctx, Ticker: ticker, Markets: markets})
+	f := m.SubscribeTickerUpdatesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, ticker, markets...)
+}
+
+// SubscribeTickerUpdatesCalls returns the calls of SubscribeTickerUpdates
+func (m *Exchange) SubscribeTickerUpdatesCalls() []SubscribeTickerUpdatesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeTickerUpdatesCall(nil), m.calls.SubscribeTickerUpdates...)
+}
+
+// SubscribeOrderUpdates calls SubscribeOrderUpdatesFunc
+func (m *Exchange) SubscribeOrderUpdates(ctx context.Context, dataCh chan<- bittrex.OrderUpdate) error {
+	m.mu.Lock()
+	m.calls.SubscribeOrderUpdates = append(m.calls.SubscribeOrderUpdates, SubscribeOrderUpdatesCall{Ctx: ctx, DataCh: dataCh})
+	f := m.SubscribeOrderUpdatesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh)
+}
+
+// SubscribeOrderUpdatesCalls returns the calls of SubscribeOrderUpdates
+func (m *Exchange) SubscribeOrderUpdatesCalls() []SubscribeOrderUpdatesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeOrderUpdatesCall(nil), m.calls.SubscribeOrderUpdates...)
+}
+
+// SubscribeOrderbookUpdates calls SubscribeOrderbookUpdatesFunc
+func (m *Exchange) SubscribeOrderbookUpdates(ctx context.Context, orderbook chan<- bittrex.OrderBook, depth int, markets ...string) error {
+	m.mu.Lock()
+	m.calls.SubscribeOrderbookUpdates = append(m.calls.SubscribeOrderbookUpdates, SubscribeOrderbookUpdatesCall{Ctx: ctx, Orderbook: orderbook, Depth: depth, Markets: markets})
+	f := m.SubscribeOrderbookUpdatesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, orderbook, depth, markets...)
+}
+
+// SubscribeOrderbookUpdatesCalls returns the calls of SubscribeOrderbookUpdates
+func (m *Exchange) SubscribeOrderbookUpdatesCalls() []SubscribeOrderbookUpdatesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeOrderbookUpdatesCall(nil), m.calls.SubscribeOrderbookUpdates...)
+}
+
+// SubscribeTrades calls SubscribeTradesFunc
+func (m *Exchange) SubscribeTrades(ctx context.Context, trades chan<- bittrex.TradeUpdate, markets ...string) error {
+	m.mu.Lock()
+	m.calls.SubscribeTrades = append(m.calls.SubscribeTrades, SubscribeTradesCall{Ctx: ctx, Trades: trades, Markets: markets})
+	f := m.SubscribeTradesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, trades, markets...)
+}
+
+// SubscribeTradesCalls returns the calls of SubscribeTrades
+func (m *Exchange) SubscribeTradesCalls() []SubscribeTradesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeTradesCall(nil), m.calls.SubscribeTrades...)
+}
+
+// SubscribeCandles calls SubscribeCandlesFunc
+func (m *Exchange) SubscribeCandles(ctx context.Context, market string, interval string, candles chan<- bittrex.CandleUpdate, seed bool) error {
+	m.mu.Lock()
+	m.calls.SubscribeCandles = append(m.calls.SubscribeCandles, SubscribeCandlesCall{Ctx: ctx, Market: market, Interval: interval, Candles: candles, Seed: seed})
+	f := m.SubscribeCandlesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, market, interval, candles, seed)
+}
+
+// SubscribeCandlesCalls returns the calls of SubscribeCandles
+func (m *Exchange) SubscribeCandlesCalls() []SubscribeCandlesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeCandlesCall(nil), m.calls.SubscribeCandles...)
+}
+
+// SubscribeBalanceUpdates calls SubscribeBalanceUpdatesFunc
+func (m *Exchange) SubscribeBalanceUpdates(ctx context.Context, dataCh chan<- bittrex.BalanceUpdate) error {
+	m.mu.Lock()
+	m.calls.SubscribeBalanceUpdates = append(m.calls.SubscribeBalanceUpdates, SubscribeBalanceUpdatesCall{Ctx: ctx, DataCh: dataCh})
+	f := m.SubscribeBalanceUpdatesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh)
+}
+
+// SubscribeBalanceUpdatesCalls returns the calls of SubscribeBalanceUpdates
+func (m *Exchange) SubscribeBalanceUpdatesCalls() []SubscribeBalanceUpdatesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeBalanceUpdatesCall(nil), m.calls.SubscribeBalanceUpdates...)
+}
+
+// SubscribeDepositUpdates calls SubscribeDepositUpdatesFunc
+func (m *Exchange) SubscribeDepositUpdates(ctx context.Context, dataCh chan<- bittrex.DepositUpdate) error {
+	m.mu.Lock()
+	m.calls.SubscribeDepositUpdates = append(m.calls.SubscribeDepositUpdates, SubscribeDepositUpdatesCall{Ctx: ctx, DataCh: dataCh})
+	f := m.SubscribeDepositUpdatesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh)
+}
+
+// SubscribeDepositUpdatesCalls returns the calls of SubscribeDepositUpdates
+func (m *Exchange) SubscribeDepositUpdatesCalls() []SubscribeDepositUpdatesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeDepositUpdatesCall(nil), m.calls.SubscribeDepositUpdates...)
+}
+
+// SubscribeExecutionUpdates calls SubscribeExecutionUpdatesFunc
+func (m *Exchange) SubscribeExecutionUpdates(ctx context.Context, dataCh chan<- bittrex.ExecutionUpdate) error {
+	m.mu.Lock()
+	m.calls.SubscribeExecutionUpdates = append(m.calls.SubscribeExecutionUpdates, SubscribeExecutionUpdatesCall{Ctx: ctx, DataCh: dataCh})
+	f := m.SubscribeExecutionUpdatesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh)
+}
+
+// SubscribeExecutionUpdatesCalls returns the calls of SubscribeExecutionUpdates
+func (m *Exchange) SubscribeExecutionUpdatesCalls() []SubscribeExecutionUpdatesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeExecutionUpdatesCall(nil), m.calls.SubscribeExecutionUpdates...)
+}
+
+// SubscribeConditionalOrderUpdates calls SubscribeConditionalOrderUpdatesFunc
+func (m *Exchange) SubscribeConditionalOrderUpdates(ctx context.Context, dataCh chan<- bittrex.ConditionalOrderUpdate) error {
+	m.mu.Lock()
+	m.calls.SubscribeConditionalOrderUpdates = append(m.calls.SubscribeConditionalOrderUpdates, SubscribeConditionalOrderUpdatesCall{Ctx: ctx, DataCh: dataCh})
+	f := m.SubscribeConditionalOrderUpdatesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh)
+}
+
+// SubscribeConditionalOrderUpdatesCalls returns the calls of SubscribeConditionalOrderUpdates
+func (m *Exchange) SubscribeConditionalOrderUpdatesCalls() []SubscribeConditionalOrderUpdatesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeConditionalOrderUpdatesCall(nil), m.calls.SubscribeConditionalOrderUpdates...)
+}
+
+// SubscribeMarketSummaries calls SubscribeMarketSummariesFunc
+func (m *Exchange) SubscribeMarketSummaries(ctx context.Context, dataCh chan<- bittrex.MarketSummariesUpdate) error {
+	m.mu.Lock()
+	m.calls.SubscribeMarketSummaries = append(m.calls.SubscribeMarketSummaries, SubscribeMarketSummariesCall{Ctx: ctx, DataCh: dataCh})
+	f := m.SubscribeMarketSummariesFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh)
+}
+
+// SubscribeMarketSummariesCalls returns the calls of SubscribeMarketSummaries
+func (m *Exchange) SubscribeMarketSummariesCalls() []SubscribeMarketSummariesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeMarketSummariesCall(nil), m.calls.SubscribeMarketSummaries...)
+}
+
+// SubscribeMarketSummary calls SubscribeMarketSummaryFunc
+func (m *Exchange) SubscribeMarketSummary(ctx context.Context, dataCh chan<- bittrex.MarketSummary, markets ...string) error {
+	m.mu.Lock()
+	m.calls.SubscribeMarketSummary = append(m.calls.SubscribeMarketSummary, SubscribeMarketSummaryCall{Ctx: ctx, DataCh: dataCh, Markets: markets})
+	f := m.SubscribeMarketSummaryFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh, markets...)
+}
+
+// SubscribeMarketSummaryCalls returns the calls of SubscribeMarketSummary
+func (m *Exchange) SubscribeMarketSummaryCalls() []SubscribeMarketSummaryCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeMarketSummaryCall(nil), m.calls.SubscribeMarketSummary...)
+}
+
+// SubscribeTickers calls SubscribeTickersFunc
+func (m *Exchange) SubscribeTickers(ctx context.Context, dataCh chan<- bittrex.TickersUpdate) error {
+	m.mu.Lock()
+	m.calls.SubscribeTickers = append(m.calls.SubscribeTickers, SubscribeTickersCall{Ctx: ctx, DataCh: dataCh})
+	f := m.SubscribeTickersFunc
+	m.mu.Unlock()
+
+	if f == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return f(ctx, dataCh)
+}
+
+// SubscribeTickersCalls returns the calls of SubscribeTickers
+func (m *Exchange) SubscribeTickersCalls() []SubscribeTickersCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SubscribeTickersCall(nil), m.calls.SubscribeTickers...)
+}
+
+// Reset forgets the recorded calls
+func (m *Exchange) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = calls{}
+}
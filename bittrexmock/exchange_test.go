@@ -0,0 +1,59 @@
+package bittrexmock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alexeykaravan/go-bittrex"
+)
+
+func TestExchange(t *testing.T) {
+	m := &Exchange{
+		GetTickerFunc: func(market string) (bittrex.Ticker, error) {
+			return bittrex.Ticker{Symbol: market}, nil
+		},
+		GetBalancesFunc: func() ([]bittrex.Balance, error) {
+			return nil, errors.New("down")
+		},
+	}
+
+	var ex bittrex.Exchange = m
+
+	if tk, err := ex.GetTicker("BTC-USD"); err != nil || tk.Symbol != "BTC-USD" {
+		t.Errorf("unexpected ticker %+v %v", tk, err)
+	}
+
+	if _, err := ex.GetBalances(); err == nil {
+		t.Error("programmed error not returned")
+	}
+
+	if o, err := ex.GetOrder("o1"); err != nil || o.ID != "" {
+		t.Errorf("unprogrammed method returned %+v %v", o, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ex.SubscribeTrades(ctx, make(chan bittrex.TradeUpdate), "BTC-USD"); err != context.Canceled {
+		t.Errorf("subscription returned %v", err)
+	}
+
+	if calls := m.GetTickerCalls(); len(calls) != 1 || calls[0].Market != "BTC-USD" {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+
+	if calls := m.SubscribeTradesCalls(); len(calls) != 1 || len(calls[0].Markets) != 1 {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+
+	var trading bittrex.Trading = m
+	trading.GetClosedOrderByClientOrderID("BTC-USD", "c1")
+	if calls := m.GetClosedOrderByClientOrderIDCalls(); len(calls) != 1 || calls[0].ClientOrderID != "c1" {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+
+	m.Reset()
+	if len(m.GetTickerCalls()) != 0 {
+		t.Error("calls not reset")
+	}
+}
@@ -0,0 +1,328 @@
+package bittrextest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexeykaravan/go-bittrex"
+)
+
+// Interaction kinds
+const (
+	KindHTTP   = "http"
+	KindStream = "stream"
+)
+
+// Scrubbed replaces secrets in cassettes
+const Scrubbed = "[scrubbed]"
+
+// secretHeaders are the request headers scrubbed from cassettes
+var secretHeaders = []string{"Api-Key", "Api-Signature", "Api-Timestamp", "Api-Content-Hash"}
+
+// Interaction is one line of a cassette: a REST exchange or a stream message
+type Interaction struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+
+	// REST exchange
+	Method         string      `json:"method,omitempty"`
+	URL            string      `json:"url,omitempty"`
+	RequestHeader  http.Header `json:"requestHeader,omitempty"`
+	RequestBody    string      `json:"requestBody,omitempty"`
+	Status         int         `json:"status,omitempty"`
+	ResponseHeader http.Header `json:"responseHeader,omitempty"`
+	ResponseBody   string      `json:"responseBody,omitempty"`
+	TransportError string      `json:"transportError,omitempty"`
+
+	// Stream message, Data is the decoded payload
+	Channel      string          `json:"channel,omitempty"`
+	StreamMethod string          `json:"streamMethod,omitempty"`
+	Data         json.RawMessage `json:"data,omitempty"`
+}
+
+// Recorder writes the REST exchanges and stream messages of a Bittrex
+// client to a JSON-lines cassette. Authentication headers are scrubbed;
+// Scrub may remove more before an interaction is written.
+type Recorder struct {
+	Scrub func(i *Interaction)
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a Recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Client returns a client recording to r
+func (r *Recorder) Client(apiKey, apiSecret string) *bittrex.Bittrex {
+	b := bittrex.NewWithCustomHTTPClient(apiKey, apiSecret, &http.Client{Transport: r.Transport(nil)})
+	r.Attach(b)
+	return b
+}
+
+// Attach records the messages of the streams of b
+func (r *Recorder) Attach(b *bittrex.Bittrex) {
+	b.OnStreamMessage(func(m bittrex.StreamMessage) {
+		r.record(Interaction{
+			Kind:         KindStream,
+			Channel:      m.Channel,
+			StreamMethod: m.Method,
+			Data:         m.Data,
+		})
+	})
+}
+
+// Transport returns a RoundTripper recording the exchanges made through
+// next, http.DefaultTransport when nil
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		i := Interaction{
+			Kind:          KindHTTP,
+			Method:        req.Method,
+			URL:           req.URL.String(),
+			RequestHeader: req.Header.Clone(),
+		}
+
+		if req.Body != nil {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			i.RequestBody = string(body)
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			i.TransportError = err.Error()
+			r.record(i)
+			return nil, err
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		i.Status = resp.StatusCode
+		i.ResponseHeader = resp.Header.Clone()
+		i.ResponseBody = string(body)
+		r.record(i)
+
+		return resp, nil
+	})
+}
+
+// Err returns the first error writing the cassette
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+func (r *Recorder) record(i Interaction) {
+	i.Time = time.Now().UTC()
+
+	for _, h := range secretHeaders {
+		if i.RequestHeader.Get(h) != "" {
+			i.RequestHeader.Set(h, Scrubbed)
+		}
+	}
+
+	if r.Scrub != nil {
+		r.Scrub(&i)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.enc.Encode(i); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// ReadCassette reads the interactions of a cassette
+func ReadCassette(r io.Reader) ([]Interaction, error) {
+	var interactions []Interaction
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20)
+
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+
+		var i Interaction
+		if err := json.Unmarshal(sc.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("cassette line %d: %w", n, err)
+		}
+		interactions = append(interactions, i)
+	}
+
+	return interactions, sc.Err()
+}
+
+// Replayer serves a cassette back. REST requests are answered with the
+// first unused exchange of the same method, path and query, whatever the
+// host. Stream messages are pushed through a Hub, in recorded order, as soon
+// as a client subscribes to their channel.
+type Replayer struct {
+	mu      sync.Mutex
+	http    []Interaction
+	used    []bool
+	pending []Interaction
+	hub     *Hub
+}
+
+// NewReplayer returns a Replayer for the cassette read from r
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	interactions, err := ReadCassette(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Replayer{}
+	for _, i := range interactions {
+		switch i.Kind {
+		case KindHTTP:
+			p.http = append(p.http, i)
+		case KindStream:
+			p.pending = append(p.pending, i)
+		}
+	}
+	p.used = make([]bool, len(p.http))
+
+	if len(p.pending) > 0 {
+		p.hub = NewHub(Scrubbed, Scrubbed)
+		p.hub.OnSubscribe(p.replay)
+	}
+
+	return p, nil
+}
+
+// Client returns a client served by the cassette
+func (p *Replayer) Client() *bittrex.Bittrex {
+	b := bittrex.NewWithCustomHTTPClient(Scrubbed, Scrubbed, &http.Client{Transport: p})
+	if p.hub != nil {
+		b.SetWSBase(p.hub.Host())
+	}
+	return b
+}
+
+// Close shuts down the stream hub
+func (p *Replayer) Close() {
+	if p.hub != nil {
+		p.hub.Close()
+	}
+}
+
+// Remaining returns the number of REST exchanges not replayed yet
+func (p *Replayer) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for _, used := range p.used {
+		if !used {
+			n++
+		}
+	}
+
+	return n
+}
+
+// RoundTrip answers req from the cassette
+func (p *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for n, i := range p.http {
+		if p.used[n] || i.Method != req.Method || !sameResource(i.URL, req.URL) {
+			continue
+		}
+		p.used[n] = true
+
+		if i.TransportError != "" {
+			return nil, fmt.Errorf("%s", i.TransportError)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+			StatusCode:    i.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        i.ResponseHeader.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(i.ResponseBody)),
+			ContentLength: int64(len(i.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette has no response for %s %s", req.Method, req.URL)
+}
+
+// replay pushes the pending messages of the channels just subscribed
+func (p *Replayer) replay(channels []string) {
+	subscribed := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		subscribed[strings.ToLower(ch)] = true
+	}
+
+	p.mu.Lock()
+	var due []Interaction
+	pending := p.pending[:0]
+	for _, i := range p.pending {
+		if subscribed[strings.ToLower(i.Channel)] {
+			due = append(due, i)
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	p.pending = pending
+	p.mu.Unlock()
+
+	for _, i := range due {
+		p.hub.Push(i.Channel, i.StreamMethod, i.Data)
+	}
+}
+
+// sameResource reports whether recorded and u have the same path and query
+func sameResource(recorded string, u *url.URL) bool {
+	r, err := url.Parse(recorded)
+	if err != nil {
+		return false
+	}
+
+	return r.Path == u.Path && r.RawQuery == u.RawQuery
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
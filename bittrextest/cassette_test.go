@@ -0,0 +1,100 @@
+package bittrextest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexeykaravan/go-bittrex"
+)
+
+// streamTicker subscribes b to the ticker of BTC-USD and returns the first message
+func streamTicker(t *testing.T, b *bittrex.Bittrex, push func()) bittrex.StreamMessage {
+	t.Helper()
+
+	msgs := make(chan bittrex.StreamMessage, 1)
+
+	s := b.NewStream()
+	defer s.Close()
+
+	if _, err := s.Subscribe(func(m bittrex.StreamMessage) { msgs <- m }, "ticker_BTC-USD"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	push()
+
+	select {
+	case m := <-msgs:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker message")
+	}
+
+	return bittrex.StreamMessage{}
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv := newTestServer(t)
+	hub := NewHub("key", "secret")
+	defer hub.Close()
+
+	var cassette bytes.Buffer
+	rec := NewRecorder(&cassette)
+
+	b := rec.Client("key", "secret")
+	b.SetAPIBase(srv.URL)
+	b.SetWSBase(hub.Host())
+
+	book := bittrex.OrderBook{MarketSymbol: "BTC-USD", Depth: 25}
+	if err := b.GetOrderBook(&book); err != nil {
+		t.Fatal(err)
+	}
+	balances, err := b.GetBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := streamTicker(t, b, func() {
+		hub.PushTicker(bittrex.Ticker{Symbol: "BTC-USD", LastTradeRate: d("100.5")})
+	})
+
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cassette.String(), `"Api-Key":["key"]`) || !strings.Contains(cassette.String(), Scrubbed) {
+		t.Fatalf("secrets not scrubbed:\n%s", cassette.String())
+	}
+
+	p, err := NewReplayer(&cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	rb := p.Client()
+
+	replayed := bittrex.OrderBook{MarketSymbol: "BTC-USD", Depth: 25}
+	if err := rb.GetOrderBook(&replayed); err != nil || replayed.Sequence != book.Sequence || len(replayed.AskDeltas) != 2 {
+		t.Errorf("unexpected book %+v %v", replayed, err)
+	}
+
+	if got, err := rb.GetBalances(); err != nil || len(got) != len(balances) || !got[0].Total.Equal(balances[0].Total) {
+		t.Errorf("unexpected balances %+v %v", got, err)
+	}
+
+	if _, err := rb.GetBalances(); err == nil {
+		t.Error("exchange replayed twice")
+	}
+	if p.Remaining() != 0 {
+		t.Errorf("%d exchanges not replayed", p.Remaining())
+	}
+
+	m := streamTicker(t, rb, func() {})
+	if m.Channel != recorded.Channel || string(m.Data) != string(recorded.Data) {
+		t.Errorf("replayed %s %s, recorded %s %s", m.Channel, m.Data, recorded.Channel, recorded.Data)
+	}
+}
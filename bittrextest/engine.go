@@ -0,0 +1,165 @@
+package bittrextest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alexeykaravan/go-bittrex"
+	"github.com/alexeykaravan/go-bittrex/internal/matching"
+	"github.com/shopspring/decimal"
+)
+
+func ticker(market string, b *matching.Book) bittrex.Ticker {
+	t := bittrex.Ticker{Symbol: market, LastTradeRate: b.Last}
+	if len(b.Bids) > 0 {
+		t.BidRate = b.Bids[0].Rate
+	}
+	if len(b.Asks) > 0 {
+		t.AskRate = b.Asks[0].Rate
+	}
+
+	return t
+}
+
+// wireOrder is an order as the API returns it
+type wireOrder struct {
+	bittrex.Order
+	UpdatedAt *string `json:"updatedAt,omitempty"`
+	ClosedAt  *string `json:"closedAt,omitempty"`
+}
+
+func wire(o *matching.Order) wireOrder {
+	w := wireOrder{Order: bittrex.Order{
+		ID:            o.ID,
+		MarketSymbol:  o.MarketSymbol,
+		Direction:     o.Direction,
+		Type:          o.Type,
+		Quantity:      o.Quantity,
+		Limit:         o.Limit,
+		TimeInForce:   o.TimeInForce,
+		ClientOrderID: o.ClientOrderID,
+		FillQuantity:  o.FillQuantity,
+		Commission:    o.Commission,
+		Proceeds:      o.Proceeds,
+		Status:        o.Status,
+	}}
+	w.CreatedAt.Time = o.CreatedAt
+
+	if !o.UpdatedAt.IsZero() {
+		t := o.UpdatedAt.Format(bittrex.TIMEFORMAT)
+		w.UpdatedAt = &t
+	}
+	if !o.ClosedAt.IsZero() {
+		t := o.ClosedAt.Format(bittrex.TIMEFORMAT)
+		w.ClosedAt = &t
+	}
+
+	return w
+}
+
+// place validates and matches a new order. It returns the existing order
+// along with the error code of a duplicate client order ID.
+func (s *Server) place(req bittrex.NewOrder) (*matching.Order, int, string) {
+	m, ok := s.markets[req.MarketSymbol]
+	if !ok || m.Status != "ONLINE" {
+		return nil, http.StatusBadRequest, "MARKET_DOES_NOT_EXIST"
+	}
+
+	o, err := s.engine.Place(matching.Symbol{
+		Symbol:       m.Symbol,
+		Base:         m.BaseCurrencySymbol,
+		Quote:        m.QuoteCurrencySymbol,
+		MinTradeSize: m.MinTradeSize,
+	}, matching.Request{
+		Direction:     req.Direction,
+		Type:          req.Type,
+		Quantity:      req.Quantity,
+		Limit:         req.Limit,
+		TimeInForce:   req.TimeInForce,
+		ClientOrderID: req.ClientOrderID,
+	})
+	if err != nil {
+		rerr := err.(*matching.Error)
+		return o, rerr.Status, rerr.Code
+	}
+
+	return o, http.StatusCreated, ""
+}
+
+// levels returns the top depth levels of one side of a market, including
+// the open orders of the account
+func (s *Server) levels(market string, book []matching.Level, direction string, depth int) []bittrex.OrderDelta {
+	levels := append([]matching.Level(nil), book...)
+
+	for _, o := range s.engine.Orders(market, true) {
+		if o.Direction == direction {
+			levels = append(levels, matching.Level{Rate: o.Limit, Quantity: o.Remaining()})
+		}
+	}
+
+	levels = matching.SortLevels(levels, direction == matching.Buy)
+	if len(levels) > depth {
+		levels = levels[:depth]
+	}
+
+	deltas := make([]bittrex.OrderDelta, len(levels))
+	for i, l := range levels {
+		deltas[i] = bittrex.OrderDelta{Rate: l.Rate, Quantity: l.Quantity}
+	}
+
+	return deltas
+}
+
+func matchingLevels(deltas []bittrex.OrderDelta) []matching.Level {
+	levels := make([]matching.Level, len(deltas))
+	for i, d := range deltas {
+		levels[i] = matching.Level{Rate: d.Rate, Quantity: d.Quantity}
+	}
+
+	return levels
+}
+
+// historyPage reads the page of closed orders the query asks for. It returns
+// the error code of a bad query.
+func historyPage(q url.Values) (matching.Page, string) {
+	var p matching.Page
+
+	if v := q.Get("pageSize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return p, "INVALID_PAGE_SIZE"
+		}
+		p.PageSize = n
+	}
+
+	for _, d := range []struct {
+		name string
+		t    *time.Time
+	}{{"startDate", &p.StartDate}, {"endDate", &p.EndDate}} {
+		if v := q.Get(d.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return p, "INVALID_DATE"
+			}
+			*d.t = t
+		}
+	}
+
+	p.NextPageToken = q.Get("nextPageToken")
+	p.PreviousPageToken = q.Get("previousPageToken")
+
+	return p, ""
+}
+
+func sortedKeys(m map[string]decimal.Decimal) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
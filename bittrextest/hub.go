@@ -0,0 +1,539 @@
+package bittrextest
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alexeykaravan/go-bittrex"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Hub is a fake of the Bittrex SignalR hub speaking the classic
+// negotiate/connect/websocket protocol for hub C3. It answers Subscribe,
+// Unsubscribe and Authenticate invocations and lets tests push messages
+// encoded like Bittrex does.
+//
+// The signalr client always dials over TLS with the default HTTP transport
+// and websocket dialer and takes no transport of its own. While hubs are open
+// those trust the certificate of the hub on top of the system roots and of
+// the TLS configuration they had, which is restored once the last hub is
+// closed, by Close or Server.Close. Other requests of the default transport
+// made while a hub opens or closes race with the change, so do not use hubs
+// in tests which call t.Parallel.
+type Hub struct {
+	*httptest.Server
+
+	APIKey    string
+	APISecret string
+
+	mu       sync.Mutex
+	tokens   map[string]bool
+	conns    map[*hubConn]struct{}
+	rejected map[string]string
+	authErr  string
+	cursor   int
+
+	onSubscribe func(channels []string)
+
+	closeOnce   sync.Once
+	releaseOnce sync.Once
+}
+
+// hubConn is a connected client
+type hubConn struct {
+	ws            *websocket.Conn
+	writeMu       sync.Mutex
+	channels      map[string]bool
+	authenticated bool
+}
+
+var upgrader = websocket.Upgrader{}
+
+// NewHub starts a Hub accepting Authenticate invocations signed with apiKey
+// and apiSecret
+func NewHub(apiKey, apiSecret string) *Hub {
+	h := &Hub{
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+		tokens:    make(map[string]bool),
+		conns:     make(map[*hubConn]struct{}),
+		rejected:  make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/signalr/negotiate", h.negotiate)
+	mux.HandleFunc("/signalr/connect", h.connect)
+
+	h.Server = httptest.NewUnstartedServer(mux)
+	h.Server.Listener = &hubListener{Listener: h.Server.Listener, h: h}
+	h.Server.StartTLS()
+	trust(h.Certificate())
+
+	return h
+}
+
+// Close disconnects all clients and shuts the hub down. It may be called
+// more than once.
+func (h *Hub) Close() {
+	h.closeOnce.Do(func() {
+		h.Disconnect()
+		h.Server.Close()
+	})
+}
+
+// hubListener releases the trust of its hub when the server closes it
+type hubListener struct {
+	net.Listener
+	h *Hub
+}
+
+func (l *hubListener) Close() error {
+	err := l.Listener.Close()
+	l.h.releaseOnce.Do(untrust)
+	return err
+}
+
+// Host returns the address to pass to Bittrex.SetWSBase
+func (h *Hub) Host() string {
+	return strings.TrimPrefix(h.URL, "https://")
+}
+
+// Client returns a Bittrex client whose streams connect to the hub
+func (h *Hub) Client() *bittrex.Bittrex {
+	b := bittrex.New(h.APIKey, h.APISecret)
+	b.SetWSBase(h.Host())
+	return b
+}
+
+// Reject makes Subscribe fail for channel with code, or succeed again when
+// code is empty
+func (h *Hub) Reject(channel, code string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if code == "" {
+		delete(h.rejected, strings.ToLower(channel))
+		return
+	}
+	h.rejected[strings.ToLower(channel)] = code
+}
+
+// RejectAuth makes Authenticate fail with code, or succeed again when code
+// is empty
+func (h *Hub) RejectAuth(code string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.authErr = code
+}
+
+// OnSubscribe sets f to be called with the channels accepted by every
+// Subscribe invocation, once its result is sent
+func (h *Hub) OnSubscribe(f func(channels []string)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.onSubscribe = f
+}
+
+// Connections returns the number of connected clients
+func (h *Hub) Connections() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.conns)
+}
+
+// Subscribed reports whether a connected client subscribed to channel
+func (h *Hub) Subscribed(channel string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.conns {
+		if c.channels[strings.ToLower(channel)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Disconnect drops all connections
+func (h *Hub) Disconnect() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.conns {
+		c.ws.Close()
+		delete(h.conns, c)
+	}
+}
+
+// Push sends v as a method message to the clients subscribed to channel,
+// or to all clients when channel is empty. Private channels only reach
+// authenticated clients.
+func (h *Hub) Push(channel, method string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestSpeed)
+	w.Write(data)
+	w.Close()
+
+	return h.send(channel, method, []interface{}{base64.StdEncoding.EncodeToString(buf.Bytes())})
+}
+
+// PushOrderBook sends an orderBook message
+func (h *Hub) PushOrderBook(book bittrex.OrderBook) error {
+	return h.Push("orderbook_"+book.MarketSymbol+"_"+strconv.Itoa(book.Depth), bittrex.ORDERBOOK, book)
+}
+
+// PushTicker sends a ticker message
+func (h *Hub) PushTicker(t bittrex.Ticker) error {
+	return h.Push("ticker_"+t.Symbol, bittrex.TICKER, t)
+}
+
+// PushTrade sends a trade message
+func (h *Hub) PushTrade(u bittrex.TradeUpdate) error {
+	return h.Push("trade_"+u.MarketSymbol, bittrex.TRADE, u)
+}
+
+// PushCandle sends a candle message
+func (h *Hub) PushCandle(u bittrex.CandleUpdate) error {
+	return h.Push("candle_"+u.MarketSymbol+"_"+u.Interval, bittrex.CANDLE, u)
+}
+
+// PushOrder sends an order message
+func (h *Hub) PushOrder(u bittrex.OrderUpdate) error {
+	return h.Push("order", bittrex.ORDER, u)
+}
+
+// PushHeartbeat sends a heartbeat to the clients subscribed to it
+func (h *Hub) PushHeartbeat() error {
+	return h.send(bittrex.HEARTBEAT, bittrex.HEARTBEAT, []interface{}{})
+}
+
+// PushAuthExpiring tells authenticated clients to authenticate again
+func (h *Hub) PushAuthExpiring() error {
+	return h.send("", bittrex.AUTHEXPIRED, []interface{}{})
+}
+
+// send writes a client method call to the matching connections
+func (h *Hub) send(channel, method string, args []interface{}) error {
+	h.mu.Lock()
+	h.cursor++
+	msg, err := json.Marshal(map[string]interface{}{
+		"C": "d-" + strconv.Itoa(h.cursor),
+		"M": []interface{}{map[string]interface{}{"H": bittrex.WSHUB, "M": method, "A": args}},
+	})
+
+	var conns []*hubConn
+	for c := range h.conns {
+		if !c.authenticated && (isPrivate(channel) || (channel == "" && method == bittrex.AUTHEXPIRED)) {
+			continue
+		}
+		if channel == "" || c.channels[strings.ToLower(channel)] {
+			conns = append(conns, c)
+		}
+	}
+	h.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for _, c := range conns {
+		if err := c.write(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isPrivate(channel string) bool {
+	switch channel {
+	case bittrex.ORDER, bittrex.BALANCE, bittrex.DEPOSIT, bittrex.EXECUTION, "conditional_order":
+		return true
+	}
+
+	return false
+}
+
+func (c *hubConn) write(msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.ws.WriteMessage(websocket.TextMessage, msg)
+}
+
+func (h *Hub) negotiate(w http.ResponseWriter, r *http.Request) {
+	token := uuid.New().String()
+
+	h.mu.Lock()
+	h.tokens[token] = true
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"Url":                     "/signalr",
+		"ConnectionToken":         token,
+		"ConnectionId":            uuid.New().String(),
+		"KeepAliveTimeout":        20.0,
+		"DisconnectTimeout":       30.0,
+		"ConnectionTimeout":       110.0,
+		"TryWebSockets":           true,
+		"ProtocolVersion":         "1.5",
+		"TransportConnectTimeout": 5.0,
+		"LogPollDelay":            0.0,
+	})
+}
+
+func (h *Hub) connect(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	h.mu.Lock()
+	known := h.tokens[q.Get("connectionToken")]
+	delete(h.tokens, q.Get("connectionToken"))
+	h.mu.Unlock()
+
+	if !known || q.Get("transport") != "webSockets" {
+		http.Error(w, "unknown connection token", http.StatusBadRequest)
+		return
+	}
+
+	var hubs []struct{ Name string }
+	if err := json.Unmarshal([]byte(q.Get("connectionData")), &hubs); err != nil || len(hubs) == 0 ||
+		!strings.EqualFold(hubs[0].Name, bittrex.WSHUB) {
+		http.Error(w, "unknown hub", http.StatusBadRequest)
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &hubConn{ws: ws, channels: make(map[string]bool)}
+
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.conns, c)
+		h.mu.Unlock()
+		ws.Close()
+	}()
+
+	if err := c.write([]byte(`{"C":"s-0","S":1,"M":[]}`)); err != nil {
+		return
+	}
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var call struct {
+			Hub       string            `json:"H"`
+			Method    string            `json:"M"`
+			Arguments []json.RawMessage `json:"A"`
+			ID        json.Number       `json:"I"`
+		}
+		if err := json.Unmarshal(data, &call); err != nil {
+			continue
+		}
+
+		res := map[string]interface{}{"I": call.ID.String()}
+		if result, err := h.invoke(c, call.Method, call.Arguments); err != nil {
+			res["E"] = err.Error()
+		} else {
+			res["R"] = result
+		}
+
+		msg, _ := json.Marshal(res)
+		if err := c.write(msg); err != nil {
+			return
+		}
+
+		if call.Method == "Subscribe" {
+			h.subscribed(c, call.Arguments)
+		}
+	}
+}
+
+// subscribed calls the OnSubscribe function with the channels c subscribed
+func (h *Hub) subscribed(c *hubConn, args []json.RawMessage) {
+	var channels []string
+	if len(args) != 1 || json.Unmarshal(args[0], &channels) != nil {
+		return
+	}
+
+	h.mu.Lock()
+	f := h.onSubscribe
+	accepted := channels[:0]
+	for _, ch := range channels {
+		if c.channels[strings.ToLower(ch)] {
+			accepted = append(accepted, ch)
+		}
+	}
+	h.mu.Unlock()
+
+	if f != nil && len(accepted) > 0 {
+		f(accepted)
+	}
+}
+
+// hubResult is the result of a hub invocation for one channel
+type hubResult struct {
+	Success   bool        `json:"Success"`
+	ErrorCode interface{} `json:"ErrorCode"`
+}
+
+// invoke runs a hub method invoked by c
+func (h *Hub) invoke(c *hubConn, method string, args []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "Subscribe", "Unsubscribe":
+		var channels []string
+		if len(args) != 1 || json.Unmarshal(args[0], &channels) != nil {
+			return nil, fmt.Errorf("%s expects a list of channels", method)
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		results := make([]hubResult, len(channels))
+		for i, ch := range channels {
+			key := strings.ToLower(ch)
+
+			switch code, rejected := h.rejected[key]; {
+			case rejected:
+				results[i] = hubResult{ErrorCode: code}
+			case method == "Subscribe" && isPrivate(key) && !c.authenticated:
+				results[i] = hubResult{ErrorCode: "UNAUTHORIZED"}
+			default:
+				c.channels[key] = method == "Subscribe"
+				results[i] = hubResult{Success: true}
+			}
+		}
+
+		return results, nil
+
+	case "Authenticate":
+		var key, random, signature string
+		var timestamp int64
+		if len(args) != 4 || json.Unmarshal(args[0], &key) != nil || json.Unmarshal(args[1], &timestamp) != nil ||
+			json.Unmarshal(args[2], &random) != nil || json.Unmarshal(args[3], &signature) != nil {
+			return nil, fmt.Errorf("Authenticate expects apiKey, timestamp, randomContent and signature")
+		}
+
+		mac := hmac.New(sha512.New, []byte(h.APISecret))
+		mac.Write([]byte(strconv.FormatInt(timestamp, 10) + random))
+
+		h.mu.Lock()
+		rejected := h.authErr
+		h.mu.Unlock()
+
+		switch {
+		case rejected != "":
+			return hubResult{ErrorCode: rejected}, nil
+		case key != h.APIKey:
+			return hubResult{ErrorCode: "APIKEY_INVALID"}, nil
+		case !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil)))):
+			return hubResult{ErrorCode: "INVALID_SIGNATURE"}, nil
+		}
+
+		h.mu.Lock()
+		c.authenticated = true
+		h.mu.Unlock()
+
+		return hubResult{Success: true}, nil
+	}
+
+	return nil, fmt.Errorf("unknown hub method %s", method)
+}
+
+var (
+	trustMu    sync.Mutex
+	trustCount int
+	savedTLS   *tls.Config
+	savedWSTLS *tls.Config
+)
+
+// trust makes the default HTTP transport and websocket dialer accept cert.
+// httptest servers share one certificate, so it is installed once for all
+// open hubs.
+func trust(cert *x509.Certificate) {
+	trustMu.Lock()
+	defer trustMu.Unlock()
+
+	trustCount++
+	if trustCount > 1 {
+		return
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	roots.AddCert(cert)
+
+	transport := http.DefaultTransport.(*http.Transport)
+	savedTLS = transport.TLSClientConfig
+	savedWSTLS = websocket.DefaultDialer.TLSClientConfig
+
+	transport.TLSClientConfig = trusting(savedTLS, roots)
+	websocket.DefaultDialer.TLSClientConfig = trusting(savedWSTLS, roots)
+}
+
+// trusting returns a copy of config, which may be nil, verifying servers
+// with roots
+func trusting(config *tls.Config, roots *x509.CertPool) *tls.Config {
+	c := &tls.Config{}
+	if config != nil {
+		c = config.Clone()
+	}
+	c.RootCAs = roots
+
+	return c
+}
+
+// untrust restores the TLS configuration trust replaced once the last hub
+// is closed
+func untrust() {
+	trustMu.Lock()
+	defer trustMu.Unlock()
+
+	if trustCount == 0 {
+		return
+	}
+
+	trustCount--
+	if trustCount > 0 {
+		return
+	}
+
+	http.DefaultTransport.(*http.Transport).TLSClientConfig = savedTLS
+	websocket.DefaultDialer.TLSClientConfig = savedWSTLS
+	savedTLS, savedWSTLS = nil, nil
+}
@@ -0,0 +1,859 @@
+package bittrextest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexeykaravan/go-bittrex"
+)
+
+// eventually waits for cond to hold
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("condition not met in time")
+}
+
+func TestHubStream(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	msgs := make(chan bittrex.StreamMessage, 10)
+
+	s := h.Client().NewStream()
+	defer s.Close()
+
+	s.Authenticate()
+	if _, err := s.Subscribe(func(m bittrex.StreamMessage) { msgs <- m }, "ticker_BTC-USD", "order"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !h.Subscribed("ticker_btc-usd") || !h.Subscribed("heartbeat") || !h.Subscribed("order") {
+		t.Fatal("channels not subscribed")
+	}
+
+	h.PushTicker(bittrex.Ticker{Symbol: "BTC-USD"})
+	h.PushTicker(bittrex.Ticker{Symbol: "ETH-USD"})
+	h.PushOrder(bittrex.OrderUpdate{Sequence: 1, Delta: bittrex.Order{ID: "o1"}})
+	h.PushHeartbeat()
+
+	for _, want := range []string{"ticker_BTC-USD", "order"} {
+		select {
+		case m := <-msgs:
+			if m.Channel != want {
+				t.Errorf("got %s, want %s", m.Channel, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s message", want)
+		}
+	}
+
+	h.Disconnect()
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not closed on disconnect")
+	}
+}
+
+func TestHubRejects(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	bad := bittrex.New("key", "wrong")
+	bad.SetWSBase(h.Host())
+
+	failed := make(chan error, 1)
+	s := bad.NewStream()
+	s.OnAuth = func(state bittrex.AuthState, err error) {
+		if state == bittrex.AuthFailed {
+			select {
+			case failed <- err:
+			default:
+			}
+		}
+	}
+	s.Authenticate()
+	s.Subscribe(func(bittrex.StreamMessage) {}, "order")
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Error("wrong secret authenticated")
+	}
+	if h.Subscribed("order") {
+		t.Error("private channel subscribed without authentication")
+	}
+
+	h.Reject("trade_BTC-USD", "INVALID_MARKET")
+
+	err := h.Client().SubscribeTrades(context.Background(), make(chan bittrex.TradeUpdate), "BTC-USD")
+	if serr, ok := err.(*bittrex.SubscribeError); !ok || serr.Channels["trade_BTC-USD"] != "INVALID_MARKET" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	h.Reject("ticker_BAD-USD", "INVALID_MARKET")
+
+	msgs := make(chan bittrex.StreamMessage, 10)
+	partial := h.Client().NewStream()
+	defer partial.Close()
+
+	sub, _ := partial.Subscribe(func(m bittrex.StreamMessage) { msgs <- m }, "ticker_BTC-USD", "ticker_BAD-USD")
+	err = partial.Connect()
+	if serr, ok := err.(*bittrex.SubscribeError); !ok || len(serr.Channels) != 1 || serr.Channels["ticker_BAD-USD"] != "INVALID_MARKET" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if ch := sub.Channels(); len(ch) != 1 || ch[0] != "ticker_BTC-USD" {
+		t.Errorf("unexpected channels %v", ch)
+	}
+
+	h.PushTicker(bittrex.Ticker{Symbol: "BTC-USD"})
+	select {
+	case m := <-msgs:
+		if m.Channel != "ticker_BTC-USD" {
+			t.Errorf("unexpected message on %s", m.Channel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("accepted channel closed with the rejected one")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tickers := make(chan bittrex.Ticker, 1)
+	go h.Client().SubscribeTickerUpdates(ctx, tickers, "ETH-USD", "BAD-USD")
+	eventually(t, func() bool { return h.Subscribed("ticker_ETH-USD") })
+
+	h.PushTicker(bittrex.Ticker{Symbol: "ETH-USD"})
+	select {
+	case tk := <-tickers:
+		if tk.Symbol != "ETH-USD" {
+			t.Errorf("unexpected ticker %+v", tk)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker for the accepted market")
+	}
+}
+
+func TestHubTypedSubscription(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	books := make(chan bittrex.OrderBook, 1)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- h.Client().SubscribeOrderbookUpdates(ctx, books, 25, "BTC-USD")
+	}()
+
+	eventually(t, func() bool { return h.Subscribed("orderbook_BTC-USD_25") })
+
+	h.PushOrderBook(bittrex.OrderBook{MarketSymbol: "BTC-USD", Depth: 25, Sequence: 7})
+
+	select {
+	case b := <-books:
+		if b.Sequence != 7 {
+			t.Errorf("unexpected book %+v", b)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no order book")
+	}
+
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("subscription returned %v", err)
+	}
+}
+
+func TestWaitForOrder(t *testing.T) {
+	s := newTestServer(t)
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	b := s.Client()
+	b.SetWSBase(h.Host())
+
+	r, err := b.NewOrder(bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+		Quantity: "0.5", Limit: "100", TimeInForce: "GOOD_TIL_CANCELLED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o bittrex.Order
+	json.Unmarshal(r, &o)
+
+	done := make(chan bittrex.Order, 1)
+	go func() {
+		filled, err := b.WaitForOrder(context.Background(), o.ID, bittrex.UntilFilled)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- filled
+	}()
+
+	eventually(t, func() bool { return h.Subscribed("order") })
+
+	s.SetOrderBook("BTC-USD", nil, []bittrex.OrderDelta{{Rate: d("100"), Quantity: d("1")}})
+	h.PushOrder(bittrex.OrderUpdate{Sequence: 1, Delta: bittrex.Order{ID: o.ID, Status: bittrex.ORDERCLOSED,
+		Quantity: d("0.5"), FillQuantity: d("0.5")}})
+
+	select {
+	case filled := <-done:
+		if filled.Status != bittrex.ORDERCLOSED || !filled.Proceeds.Equal(d("50")) {
+			t.Errorf("unexpected order %+v", filled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForOrder did not return")
+	}
+}
+
+func TestWaitForOrderStaleRead(t *testing.T) {
+	s := newTestServer(t)
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	b := s.Client()
+	b.SetWSBase(h.Host())
+
+	r, err := b.NewOrder(bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+		Quantity: "0.5", Limit: "100", TimeInForce: "GOOD_TIL_CANCELLED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o bittrex.Order
+	json.Unmarshal(r, &o)
+
+	type result struct {
+		o   bittrex.Order
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		filled, err := b.WaitForOrder(context.Background(), o.ID, bittrex.UntilFilled)
+		done <- result{filled, err}
+	}()
+
+	eventually(t, func() bool { return h.Subscribed("order") })
+
+	// the REST server still has the order open
+	h.PushOrder(bittrex.OrderUpdate{Sequence: 1, Delta: bittrex.Order{ID: o.ID, Status: bittrex.ORDERCLOSED,
+		Quantity: d("0.5"), FillQuantity: d("0.5")}})
+
+	select {
+	case res := <-done:
+		if res.err != nil || res.o.Status != bittrex.ORDERCLOSED || !res.o.FillQuantity.Equal(d("0.5")) {
+			t.Errorf("unexpected order %+v %v", res.o, res.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForOrder did not return")
+	}
+}
+
+func TestStreamRunReconnects(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	states := make(chan bittrex.StreamState, 10)
+	gaps := make(chan struct{}, 1)
+	msgs := make(chan bittrex.StreamMessage, 10)
+
+	s := h.Client().NewStream()
+	s.MinBackoff = 0
+	s.MaxBackoff = 0
+	s.HeartbeatTimeout = 0
+	s.OnState = func(state bittrex.StreamState, err error) { states <- state }
+	s.OnGap = func() { gaps <- struct{}{} }
+
+	if _, err := s.Subscribe(func(m bittrex.StreamMessage) { msgs <- m }, "ticker_BTC-USD"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- s.Run(ctx) }()
+
+	expect := func(want ...bittrex.StreamState) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-states:
+				if got != w {
+					t.Fatalf("got state %s, want %s", got, w)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("no %s state", w)
+			}
+		}
+	}
+
+	expect(bittrex.StreamConnecting, bittrex.StreamConnected)
+
+	h.Disconnect()
+	expect(bittrex.StreamReconnecting, bittrex.StreamConnected)
+
+	select {
+	case <-gaps:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnGap not called")
+	}
+
+	if !h.Subscribed("ticker_BTC-USD") || !h.Subscribed("heartbeat") {
+		t.Fatal("channels not subscribed again")
+	}
+
+	h.PushTicker(bittrex.Ticker{Symbol: "BTC-USD"})
+	select {
+	case m := <-msgs:
+		if m.Channel != "ticker_BTC-USD" {
+			t.Errorf("unexpected message on %s", m.Channel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message after reconnect")
+	}
+
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("Run returned %v", err)
+	}
+	expect(bittrex.StreamClosed)
+}
+
+func TestStreamAuthRetries(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	states := make(chan bittrex.AuthState, 100)
+
+	s := h.Client().NewStream()
+	s.MinBackoff = 10 * time.Millisecond
+	s.OnAuth = func(state bittrex.AuthState, err error) { states <- state }
+	defer s.Close()
+
+	next := func() bittrex.AuthState {
+		t.Helper()
+		select {
+		case st := <-states:
+			return st
+		case <-time.After(5 * time.Second):
+			t.Fatal("no authentication state")
+		}
+		return 0
+	}
+	await := func(want bittrex.AuthState) {
+		t.Helper()
+		for next() != want {
+		}
+	}
+
+	h.RejectAuth("INVALID_SIGNATURE")
+	s.Authenticate()
+	s.Subscribe(func(bittrex.StreamMessage) {}, "order", "ticker_BTC-USD")
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if st := next(); st != bittrex.AuthFailed {
+		t.Fatalf("got %s, want failed", st)
+	}
+	if h.Subscribed("order") || !h.Subscribed("ticker_BTC-USD") {
+		t.Fatal("private channel subscribed before authentication")
+	}
+
+	h.RejectAuth("")
+	await(bittrex.AuthAuthenticated)
+	eventually(t, func() bool { return h.Subscribed("order") })
+
+	h.PushAuthExpiring()
+	for _, want := range []bittrex.AuthState{bittrex.AuthExpiring, bittrex.AuthAuthenticated} {
+		if st := next(); st != want {
+			t.Fatalf("got %s, want %s", st, want)
+		}
+	}
+
+	h.RejectAuth("INVALID_SIGNATURE")
+	h.PushAuthExpiring()
+	for _, want := range []bittrex.AuthState{bittrex.AuthExpiring, bittrex.AuthFailed, bittrex.AuthFailed} {
+		if st := next(); st != want {
+			t.Fatalf("got %s, want %s", st, want)
+		}
+	}
+
+	h.RejectAuth("")
+	await(bittrex.AuthAuthenticated)
+}
+
+func TestSubscribeDecodeError(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	b := h.Client()
+	b.OnStreamError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	tickers := make(chan bittrex.Ticker, 1)
+	go b.SubscribeTickerUpdates(ctx, tickers, "BTC-USD")
+	eventually(t, func() bool { return h.Subscribed("ticker_BTC-USD") })
+
+	h.Push("ticker_BTC-USD", bittrex.TICKER, map[string]string{"symbol": "BTC-USD", "bidRate": "x"})
+
+	select {
+	case err := <-errs:
+		if !strings.HasPrefix(err.Error(), "ticker_BTC-USD: ") {
+			t.Errorf("unexpected error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("decoding error not reported")
+	}
+
+	select {
+	case tk := <-tickers:
+		t.Errorf("undecodable ticker delivered: %+v", tk)
+	default:
+	}
+}
+
+func TestSharedStreamSubscriptions(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	s := h.Client().NewStream()
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tradesCtx, stopTrades := context.WithCancel(ctx)
+
+	tickers := make(chan bittrex.Ticker, 1)
+	trades := make(chan bittrex.TradeUpdate, 1)
+	errs := make(chan error, 1)
+	go s.SubscribeTickerUpdates(ctx, tickers, "BTC-USD")
+	go func() { errs <- s.SubscribeTrades(tradesCtx, trades, "BTC-USD") }()
+
+	eventually(t, func() bool { return h.Subscribed("ticker_BTC-USD") && h.Subscribed("trade_BTC-USD") })
+	if n := h.Connections(); n != 1 {
+		t.Fatalf("%d connections, want 1", n)
+	}
+
+	h.PushTicker(bittrex.Ticker{Symbol: "BTC-USD"})
+	h.PushTrade(bittrex.TradeUpdate{MarketSymbol: "BTC-USD", Sequence: 3})
+
+	select {
+	case <-tickers:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker")
+	}
+	select {
+	case u := <-trades:
+		if u.Sequence != 3 {
+			t.Errorf("unexpected trade %+v", u)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trade")
+	}
+
+	stopTrades()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("trades returned %v", err)
+	}
+	eventually(t, func() bool { return !h.Subscribed("trade_BTC-USD") })
+
+	select {
+	case <-s.Done():
+		t.Fatal("shared stream closed with a subscription")
+	default:
+	}
+}
+
+func TestSubscribeCandlesSeeded(t *testing.T) {
+	s := newTestServer(t)
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	b := s.Client()
+	b.SetWSBase(h.Host())
+
+	s.SetCandles("BTC-USD", "MINUTE_1", []bittrex.Candle{{Close: d("1")}, {Close: d("2")}})
+	// hold the REST seed so a stream update arrives while seeding
+	s.Inject(Fault{Path: "markets/BTC-USD/candles/MINUTE_1/recent", Latency: 300 * time.Millisecond, Times: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := b.SubscribeCandles(ctx, "BTC-USD", "SECOND_1", make(chan bittrex.CandleUpdate), false); err == nil {
+		t.Error("wrong interval accepted")
+	}
+
+	candles := make(chan bittrex.CandleUpdate, 10)
+	go b.SubscribeCandles(ctx, "btc-usd", "MINUTE_1", candles, true)
+
+	eventually(t, func() bool { return h.Subscribed("candle_BTC-USD_MINUTE_1") })
+	h.PushCandle(bittrex.CandleUpdate{Sequence: 5, MarketSymbol: "BTC-USD", Interval: "MINUTE_1", Delta: bittrex.Candle{Close: d("3")}})
+
+	next := func() bittrex.CandleUpdate {
+		t.Helper()
+		select {
+		case c := <-candles:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("no candle")
+		}
+		return bittrex.CandleUpdate{}
+	}
+
+	for i, want := range []string{"1", "2", "3"} {
+		c := next()
+		if !c.Delta.Close.Equal(d(want)) || c.MarketSymbol != "BTC-USD" || c.Interval != "MINUTE_1" {
+			t.Fatalf("candle %d: got %+v, want close %s", i, c, want)
+		}
+		if (c.Sequence == 0) != (i < 2) {
+			t.Errorf("candle %d has sequence %d", i, c.Sequence)
+		}
+	}
+
+	h.PushCandle(bittrex.CandleUpdate{Sequence: 6, MarketSymbol: "BTC-USD", Interval: "MINUTE_1", Delta: bittrex.Candle{Close: d("4")}})
+	if c := next(); c.Sequence != 6 {
+		t.Errorf("got %+v after the seed, want sequence 6", c)
+	}
+}
+
+func TestMarketSummaryCache(t *testing.T) {
+	s := newTestServer(t)
+	s.AddMarket(bittrex.Market{BaseCurrencySymbol: "ETH", QuoteCurrencySymbol: "USD"})
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	b := s.Client()
+	b.SetWSBase(h.Host())
+
+	summary := func(symbol, high string, at time.Time) bittrex.MarketSummary {
+		m := bittrex.MarketSummary{Symbol: symbol, High: d(high)}
+		m.UpdatedAt.Time = at
+		return m
+	}
+	high := func(c *bittrex.MarketSummaryCache, symbol, want string) func() bool {
+		return func() bool {
+			m, ok := c.Get(symbol)
+			return ok && m.High.Equal(d(want))
+		}
+	}
+
+	t0 := time.Now().UTC().Truncate(time.Millisecond)
+	s.SetMarketSummary(summary("BTC-USD", "1", t0))
+	s.SetMarketSummary(summary("ETH-USD", "10", t0))
+
+	gaps := make(chan error, 1)
+	c := b.NewMarketSummaryCache()
+	c.OnError = func(err error) {
+		if errors.Is(err, bittrex.ErrSequenceGap) {
+			select {
+			case gaps <- err:
+			default:
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, nil)
+
+	eventually(t, high(c, "btc-usd", "1"))
+	eventually(t, func() bool { return h.Subscribed("market_summaries") })
+
+	h.Push("market_summaries", bittrex.MARKETSUMMARIES, bittrex.MarketSummariesUpdate{
+		Sequence: 1, Deltas: []bittrex.MarketSummary{summary("BTC-USD", "2", t0.Add(time.Second))}})
+	eventually(t, high(c, "BTC-USD", "2"))
+
+	// the gap reloads every summary, ETH-USD only changed on the server
+	s.SetMarketSummary(summary("ETH-USD", "20", t0.Add(2*time.Second)))
+	h.Push("market_summaries", bittrex.MARKETSUMMARIES, bittrex.MarketSummariesUpdate{
+		Sequence: 5, Deltas: []bittrex.MarketSummary{summary("BTC-USD", "3", t0.Add(3*time.Second))}})
+
+	select {
+	case <-gaps:
+	case <-time.After(5 * time.Second):
+		t.Fatal("gap not reported")
+	}
+
+	eventually(t, high(c, "ETH-USD", "20"))
+	if !high(c, "BTC-USD", "3")() {
+		t.Error("resync replaced a newer summary")
+	}
+	if n := len(c.All()); n != 2 {
+		t.Errorf("%d summaries cached", n)
+	}
+}
+
+func TestSubscribeBroadcasts(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := h.Client().NewStream()
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	summaries := make(chan bittrex.MarketSummariesUpdate, 1)
+	summary := make(chan bittrex.MarketSummary, 1)
+	tickers := make(chan bittrex.TickersUpdate, 1)
+	go s.SubscribeMarketSummaries(ctx, summaries)
+	go s.SubscribeMarketSummary(ctx, summary, "eth-usd")
+	go s.SubscribeTickers(ctx, tickers)
+
+	eventually(t, func() bool {
+		return h.Subscribed("market_summaries") && h.Subscribed("market_summary_ETH-USD") && h.Subscribed("tickers")
+	})
+
+	h.Push("market_summaries", bittrex.MARKETSUMMARIES, bittrex.MarketSummariesUpdate{Sequence: 4,
+		Deltas: []bittrex.MarketSummary{{Symbol: "BTC-USD", High: d("2")}, {Symbol: "ETH-USD", High: d("3")}}})
+	h.Push("market_summary_ETH-USD", bittrex.MARKETSUMMARY, bittrex.MarketSummary{Symbol: "ETH-USD", Low: d("1")})
+	h.Push("tickers", bittrex.TICKERS, bittrex.TickersUpdate{Sequence: 9,
+		Deltas: []bittrex.Ticker{{Symbol: "BTC-USD", LastTradeRate: d("100")}}})
+
+	select {
+	case u := <-summaries:
+		if u.Sequence != 4 || len(u.Deltas) != 2 || !u.Deltas[1].High.Equal(d("3")) {
+			t.Errorf("unexpected summaries %+v", u)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no market summaries")
+	}
+
+	select {
+	case m := <-summary:
+		if m.Symbol != "ETH-USD" || !m.Low.Equal(d("1")) {
+			t.Errorf("unexpected summary %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no market summary")
+	}
+
+	select {
+	case u := <-tickers:
+		if u.Sequence != 9 || len(u.Deltas) != 1 || !u.Deltas[0].LastTradeRate.Equal(d("100")) {
+			t.Errorf("unexpected tickers %+v", u)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tickers")
+	}
+}
+
+func TestWaitForOrderPolls(t *testing.T) {
+	s := newTestServer(t)
+	b := s.Client()
+
+	// a hub which never completes the handshake keeps the stream connecting
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	b.SetWSBase(ln.Addr().String())
+
+	defer func(interval time.Duration) { bittrex.OrderPollInterval = interval }(bittrex.OrderPollInterval)
+	bittrex.OrderPollInterval = 20 * time.Millisecond
+
+	r, err := b.NewOrder(bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+		Quantity: "0.5", Limit: "100", TimeInForce: "GOOD_TIL_CANCELLED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o bittrex.Order
+	json.Unmarshal(r, &o)
+
+	s.Inject(Fault{Method: "GET", Path: "orders/" + o.ID, Status: http.StatusServiceUnavailable, Code: "SERVICE_UNAVAILABLE", Times: 1})
+	s.RateLimit(1)
+
+	done := make(chan error, 1)
+	go func() {
+		filled, err := b.WaitForOrder(context.Background(), o.ID, bittrex.UntilFilled)
+		if err == nil && filled.Status != bittrex.ORDERCLOSED {
+			err = fmt.Errorf("unexpected order %+v", filled)
+		}
+		done <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	s.SetOrderBook("BTC-USD", nil, []bittrex.OrderDelta{{Rate: d("100"), Quantity: d("1")}})
+
+	// well before the 5s stream connect timeout
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForOrder did not poll")
+	}
+
+	if _, err := b.WaitForOrder(context.Background(), "unknown", bittrex.UntilClosed); err == nil {
+		t.Error("unknown order waited for")
+	}
+}
+
+func TestWaitForOrderSharedStream(t *testing.T) {
+	s := newTestServer(t)
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	b := s.Client()
+	b.SetWSBase(h.Host())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := b.NewStream()
+	st.Authenticate()
+	go st.Run(ctx)
+
+	var ids []string
+	for _, limit := range []string{"100", "99"} {
+		r, err := b.NewOrder(bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+			Quantity: "0.5", Limit: limit, TimeInForce: "GOOD_TIL_CANCELLED"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var o bittrex.Order
+		json.Unmarshal(r, &o)
+		ids = append(ids, o.ID)
+	}
+
+	done := make(chan error, len(ids))
+	for _, id := range ids {
+		go func(id string) {
+			_, err := st.WaitForOrder(ctx, id, bittrex.UntilPartiallyFilled)
+			done <- err
+		}(id)
+	}
+
+	eventually(t, func() bool { return h.Subscribed("order") })
+	if n := h.Connections(); n != 1 {
+		t.Fatalf("%d connections, want 1", n)
+	}
+
+	for i, id := range ids {
+		h.PushOrder(bittrex.OrderUpdate{Sequence: i + 1, Delta: bittrex.Order{ID: id, Status: bittrex.ORDEROPEN,
+			Quantity: d("0.5"), FillQuantity: d("0.1")}})
+	}
+
+	for range ids {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("WaitForOrder did not return")
+		}
+	}
+}
+
+func TestHubTrust(t *testing.T) {
+	transport := http.DefaultTransport.(*http.Transport)
+	defer func(saved *tls.Config) { transport.TLSClientConfig = saved }(transport.TLSClientConfig)
+
+	previous := &tls.Config{MinVersion: tls.VersionTLS12}
+	transport.TLSClientConfig = previous
+
+	first, second := NewHub("key", "secret"), NewHub("key", "secret")
+	first.Close()
+	first.Close()
+
+	if c := transport.TLSClientConfig; c == previous || c.RootCAs == nil || c.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("second hub not trusted on top of the previous configuration: %+v", c)
+	}
+
+	s := second.Client().NewStream()
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// closing the server directly releases the trust too
+	second.Server.Close()
+	second.Close()
+	if transport.TLSClientConfig != previous {
+		t.Error("TLS configuration not restored")
+	}
+}
+
+// connectMetrics records the stream label of every connect
+type connectMetrics struct {
+	mu      sync.Mutex
+	streams []string
+}
+
+func (m *connectMetrics) ObserveRequest(string, string, int, string, time.Duration) {}
+func (m *connectMetrics) StreamMessage(string, string)                              {}
+func (m *connectMetrics) StreamDropped(string, string)                              {}
+func (m *connectMetrics) StreamDisconnect(string)                                   {}
+func (m *connectMetrics) StreamReconnect(string)                                    {}
+
+func (m *connectMetrics) StreamConnect(stream string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.streams = append(m.streams, stream)
+}
+
+func TestStreamMetricsName(t *testing.T) {
+	h := NewHub("key", "secret")
+	defer h.Close()
+
+	m := &connectMetrics{}
+	b := h.Client()
+	b.SetMetrics(m)
+
+	named, unnamed := b.NewStream(), b.NewStream()
+	named.Name = "book"
+	for _, s := range []*bittrex.Stream{named, unnamed} {
+		if err := s.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		s.Close()
+	}
+
+	if len(m.streams) != 2 || m.streams[0] != "book" || m.streams[1] != "default" {
+		t.Errorf("unexpected stream labels %v", m.streams)
+	}
+}
@@ -0,0 +1,484 @@
+// Package bittrextest provides fakes of the Bittrex v3 API for tests
+package bittrextest
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexeykaravan/go-bittrex"
+	"github.com/alexeykaravan/go-bittrex/internal/matching"
+	"github.com/shopspring/decimal"
+)
+
+// TimestampWindow is how far Api-Timestamp may be from the server clock
+const TimestampWindow = time.Minute
+
+// Fault is a failure injected into the requests matching Method and Path
+type Fault struct {
+	// Method matches any method when empty
+	Method string
+	// Path is the path below /v3/, like "orders" or "markets/BTC-USD/ticker".
+	// It matches any path when empty.
+	Path string
+	// Latency delays the response
+	Latency time.Duration
+	// Status is the error status returned, 0 serves the request after Latency
+	Status int
+	// Code is the error code of the response body
+	Code string
+	// Times is the number of requests affected, 0 for all of them
+	Times int
+}
+
+// Server is a fake of the v3 REST API. It keeps markets, tickers, order
+// books and the balances and orders of one account, matching new orders
+// against the order books.
+type Server struct {
+	*httptest.Server
+
+	APIKey    string
+	APISecret string
+
+	mu      sync.Mutex
+	markets map[string]bittrex.Market
+	symbols []string
+	tickers map[string]bittrex.Ticker
+	candles map[string][]bittrex.Candle
+	summary map[string]bittrex.MarketSummary
+	engine  *matching.Engine
+	faults  []*Fault
+}
+
+// NewServer starts a Server accepting requests signed with apiKey and apiSecret
+func NewServer(apiKey, apiSecret string) *Server {
+	s := &Server{
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+		markets:   make(map[string]bittrex.Market),
+		tickers:   make(map[string]bittrex.Ticker),
+		candles:   make(map[string][]bittrex.Candle),
+		summary:   make(map[string]bittrex.MarketSummary),
+		engine:    matching.New(),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns a Bittrex client using the server
+func (s *Server) Client() *bittrex.Bittrex {
+	b := bittrex.New(s.APIKey, s.APISecret)
+	b.SetAPIBase(s.URL)
+	return b
+}
+
+// AddMarket adds a market. Its symbol is made of BaseCurrencySymbol and
+// QuoteCurrencySymbol when not set.
+func (s *Server) AddMarket(m bittrex.Market) {
+	if m.Symbol == "" {
+		m.Symbol = m.BaseCurrencySymbol + "-" + m.QuoteCurrencySymbol
+	}
+	if m.Status == "" {
+		m.Status = "ONLINE"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.markets[m.Symbol]; !ok {
+		s.symbols = append(s.symbols, m.Symbol)
+		s.engine.Books[m.Symbol] = &matching.Book{}
+	}
+	s.markets[m.Symbol] = m
+}
+
+// SetTicker sets the ticker of a market
+func (s *Server) SetTicker(t bittrex.Ticker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tickers[t.Symbol] = t
+}
+
+// SetMarketSummary sets the summary of a market
+func (s *Server) SetMarketSummary(m bittrex.MarketSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.summary[m.Symbol] = m
+}
+
+// SetCandles sets the recent candles of a market for interval
+func (s *Server) SetCandles(market, interval string, candles []bittrex.Candle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.candles[market+"/"+interval] = candles
+}
+
+// SetOrderBook replaces the liquidity of a market and fills the open orders
+// crossing it at their limit, the way PaperTrader.Update does
+func (s *Server) SetOrderBook(market string, bids, asks []bittrex.OrderDelta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.engine.SetBook(market, matchingLevels(bids), matchingLevels(asks))
+
+	for _, o := range s.engine.Orders(market, true) {
+		s.engine.Match(o, true)
+	}
+}
+
+// SetBalance sets the total balance of a currency
+func (s *Server) SetBalance(currency string, total decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.engine.Balances[currency] = total
+}
+
+// SetFee sets the commission charged on the proceeds of every fill, 0 by default
+func (s *Server) SetFee(rate decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.engine.MakerFee = rate
+	s.engine.TakerFee = rate
+}
+
+// SetFees sets the commission charged on the proceeds of maker and taker
+// fills apart. Orders taking liquidity when placed are takers, resting
+// orders filled by SetOrderBook makers.
+func (s *Server) SetFees(maker, taker decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.engine.MakerFee = maker
+	s.engine.TakerFee = taker
+}
+
+// Inject adds a fault. Faults are tried in the order they were added.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// RateLimit answers the next times requests with 429 Too Many Requests
+func (s *Server) RateLimit(times int) {
+	s.Inject(Fault{Status: http.StatusTooManyRequests, Code: "TOO_MANY_REQUESTS", Times: times})
+}
+
+// ClearFaults removes all faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// fault returns the fault for a request, if any
+func (s *Server) fault(method, path string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if (f.Method != "" && f.Method != method) || (f.Path != "" && f.Path != path) {
+			continue
+		}
+
+		hit := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+
+		return &hit
+	}
+
+	return nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BAD_REQUEST")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+bittrex.APIVERSION+"/")
+	if path == r.URL.Path {
+		writeError(w, http.StatusNotFound, "NOT_FOUND")
+		return
+	}
+
+	if f := s.fault(r.Method, path); f != nil {
+		time.Sleep(f.Latency)
+
+		if f.Status != 0 {
+			writeError(w, f.Status, f.Code)
+			return
+		}
+	}
+
+	parts := strings.Split(path, "/")
+
+	if parts[0] == "markets" {
+		s.serveMarkets(w, r, parts[1:])
+		return
+	}
+
+	if code := s.authenticate(r, body); code != "" {
+		writeError(w, http.StatusUnauthorized, code)
+		return
+	}
+
+	switch {
+	case path == "balances" && r.Method == "GET":
+		s.serveBalances(w)
+	case parts[0] == "orders":
+		s.serveOrders(w, r, parts[1:], body)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+// authenticate checks the request headers the way Client.do makes them and
+// returns the error code of a rejected request
+func (s *Server) authenticate(r *http.Request, body []byte) string {
+	if r.Header.Get("Api-Key") != s.APIKey {
+		return "APIKEY_INVALID"
+	}
+
+	ts, err := strconv.ParseInt(r.Header.Get("Api-Timestamp"), 10, 64)
+	if err != nil {
+		return "INVALID_TIMESTAMP"
+	}
+	if d := time.Since(time.Unix(0, ts*int64(time.Millisecond))); d > TimestampWindow || d < -TimestampWindow {
+		return "INVALID_TIMESTAMP"
+	}
+
+	hash := sha512.Sum512(body)
+	contentHash := hex.EncodeToString(hash[:])
+	if r.Header.Get("Api-Content-Hash") != contentHash {
+		return "INVALID_CONTENT_HASH"
+	}
+
+	mac := hmac.New(sha512.New, []byte(s.APISecret))
+	mac.Write([]byte(r.Header.Get("Api-Timestamp") + s.URL + r.URL.RequestURI() + r.Method + contentHash))
+	if !hmac.Equal([]byte(r.Header.Get("Api-Signature")), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+		return "INVALID_SIGNATURE"
+	}
+
+	return ""
+}
+
+func (s *Server) serveMarkets(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(parts) == 0 || parts[0] == "":
+		markets := make([]bittrex.Market, 0, len(s.symbols))
+		for _, sym := range s.symbols {
+			markets = append(markets, s.markets[sym])
+		}
+		writeJSON(w, http.StatusOK, markets)
+
+	case len(parts) == 1 && parts[0] == "tickers":
+		tickers := make([]bittrex.Ticker, 0, len(s.tickers))
+		for _, sym := range s.symbols {
+			if t, ok := s.tickers[sym]; ok {
+				tickers = append(tickers, t)
+			}
+		}
+		writeJSON(w, http.StatusOK, tickers)
+
+	case len(parts) == 1 && parts[0] == "summaries":
+		summaries := make([]bittrex.MarketSummary, 0, len(s.summary))
+		for _, sym := range s.symbols {
+			if m, ok := s.summary[sym]; ok {
+				summaries = append(summaries, m)
+			}
+		}
+		writeJSON(w, http.StatusOK, summaries)
+
+	case len(parts) == 2:
+		market := strings.ToUpper(parts[0])
+		if _, ok := s.markets[market]; !ok {
+			writeError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+			return
+		}
+
+		switch parts[1] {
+		case "ticker":
+			t, ok := s.tickers[market]
+			if !ok {
+				t = ticker(market, s.engine.Books[market])
+			}
+			writeJSON(w, http.StatusOK, t)
+
+		case "summary":
+			writeJSON(w, http.StatusOK, s.summary[market])
+
+		case "orderbook":
+			depth := 25
+			if d := r.URL.Query().Get("depth"); d != "" {
+				depth, _ = strconv.Atoi(d)
+			}
+			if !bittrex.ORDERBOOKDEPTHS[depth] {
+				writeError(w, http.StatusBadRequest, "INVALID_DEPTH")
+				return
+			}
+
+			b := s.engine.Books[market]
+			w.Header().Set("Sequence", strconv.Itoa(b.Sequence))
+			writeJSON(w, http.StatusOK, bittrex.OrderBook2{
+				BidDeltas: s.levels(market, b.Bids, matching.Buy, depth),
+				AskDeltas: s.levels(market, b.Asks, matching.Sell, depth),
+			})
+
+		default:
+			writeError(w, http.StatusNotFound, "NOT_FOUND")
+		}
+
+	case len(parts) == 4 && parts[1] == "candles" && parts[3] == "recent":
+		market := strings.ToUpper(parts[0])
+		if _, ok := s.markets[market]; !ok {
+			writeError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+			return
+		}
+		if !bittrex.CANDLEINTERVALS[parts[2]] {
+			writeError(w, http.StatusBadRequest, "INVALID_CANDLE_INTERVAL")
+			return
+		}
+
+		candles := s.candles[market+"/"+parts[2]]
+		if candles == nil {
+			candles = []bittrex.Candle{}
+		}
+		writeJSON(w, http.StatusOK, candles)
+
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+func (s *Server) serveBalances(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reserved := s.engine.Reserved()
+
+	balances := make([]bittrex.Balance, 0, len(s.engine.Balances))
+	for _, c := range sortedKeys(s.engine.Balances) {
+		total := s.engine.Balances[c]
+		balances = append(balances, bittrex.Balance{
+			CurrencySymbol: c,
+			Total:          total,
+			Available:      total.Sub(reserved[c]),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, balances)
+}
+
+func (s *Server) serveOrders(w http.ResponseWriter, r *http.Request, parts []string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(parts) == 0 && r.Method == "POST":
+		var req bittrex.NewOrder
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_JSON")
+			return
+		}
+
+		o, status, code := s.place(req)
+		if code != "" {
+			if o != nil {
+				writeJSON(w, status, map[string]interface{}{"code": code, "data": map[string]string{"existingOrderId": o.ID}})
+				return
+			}
+			writeError(w, status, code)
+			return
+		}
+		writeJSON(w, http.StatusCreated, wire(o))
+
+	case len(parts) == 1 && (parts[0] == "open" || parts[0] == "closed") && r.Method == "GET":
+		market := strings.ToUpper(r.URL.Query().Get("marketSymbol"))
+		sorted := s.engine.Orders(market, true)
+		if parts[0] == "closed" {
+			page, code := historyPage(r.URL.Query())
+			if code != "" {
+				writeError(w, http.StatusBadRequest, code)
+				return
+			}
+
+			var err error
+			if sorted, err = s.engine.History(market, page); err != nil {
+				rerr := err.(*matching.Error)
+				writeError(w, rerr.Status, rerr.Code)
+				return
+			}
+		}
+
+		orders := []wireOrder{}
+		for _, o := range sorted {
+			orders = append(orders, wire(o))
+		}
+		writeJSON(w, http.StatusOK, orders)
+
+	case len(parts) == 1 && r.Method == "GET":
+		o, ok := s.engine.Order(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "NOT_FOUND")
+			return
+		}
+		writeJSON(w, http.StatusOK, wire(o))
+
+	case len(parts) == 1 && r.Method == "DELETE":
+		o, ok := s.engine.Order(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "NOT_FOUND")
+			return
+		}
+		if o.Status != bittrex.ORDEROPEN {
+			writeError(w, http.StatusConflict, "ORDER_NOT_OPEN")
+			return
+		}
+		s.engine.Close(o)
+		writeJSON(w, http.StatusOK, wire(o))
+
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"code": code})
+}
@@ -0,0 +1,245 @@
+package bittrextest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alexeykaravan/go-bittrex"
+	"github.com/shopspring/decimal"
+)
+
+func d(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer("key", "secret")
+	t.Cleanup(s.Close)
+
+	s.AddMarket(bittrex.Market{BaseCurrencySymbol: "BTC", QuoteCurrencySymbol: "USD", MinTradeSize: d("0.001")})
+	s.SetOrderBook("BTC-USD",
+		[]bittrex.OrderDelta{{Rate: d("99"), Quantity: d("1")}, {Rate: d("98"), Quantity: d("2")}},
+		[]bittrex.OrderDelta{{Rate: d("101"), Quantity: d("1")}, {Rate: d("102"), Quantity: d("2")}})
+	s.SetBalance("USD", d("1000"))
+	s.SetBalance("BTC", d("1"))
+
+	return s
+}
+
+func TestServerMarketData(t *testing.T) {
+	s := newTestServer(t)
+	b := s.Client()
+
+	markets, err := b.GetMarkets()
+	if err != nil || len(markets) != 1 || markets[0].Symbol != "BTC-USD" {
+		t.Fatalf("unexpected markets %+v %v", markets, err)
+	}
+
+	ticker, err := b.GetTicker("btc-usd")
+	if err != nil || !ticker.BidRate.Equal(d("99")) || !ticker.AskRate.Equal(d("101")) {
+		t.Errorf("unexpected ticker %+v %v", ticker, err)
+	}
+
+	book := bittrex.OrderBook{MarketSymbol: "BTC-USD", Depth: 1}
+	if err := b.GetOrderBook(&book); err != nil {
+		t.Fatal(err)
+	}
+	if book.Sequence != 1 || len(book.BidDeltas) != 1 || !book.AskDeltas[0].Rate.Equal(d("101")) {
+		t.Errorf("unexpected book %+v", book)
+	}
+}
+
+func TestServerOrders(t *testing.T) {
+	s := newTestServer(t)
+	s.SetFee(d("0.01"))
+	b := s.Client()
+
+	r, err := b.NewOrder(bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+		Quantity: "1.5", Limit: "101.5", TimeInForce: "GOOD_TIL_CANCELLED", ClientOrderID: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o bittrex.Order
+	if err := json.Unmarshal(r, &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != bittrex.ORDEROPEN || !o.FillQuantity.Equal(d("1")) || !o.Proceeds.Equal(d("101")) {
+		t.Errorf("unexpected order %+v", o)
+	}
+
+	_, err = b.NewOrder(bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+		Quantity: "0.1", Limit: "90", ClientOrderID: "c1"})
+	var apiErr *bittrex.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "DUPLICATE_CLIENT_ORDER_ID" {
+		t.Errorf("duplicate client order ID accepted: %v", err)
+	}
+
+	// the resting half fills at its limit once the book moves
+	s.SetOrderBook("BTC-USD", nil, []bittrex.OrderDelta{{Rate: d("100"), Quantity: d("5")}})
+
+	o, err = b.GetOrder(o.ID)
+	if err != nil || o.Status != bittrex.ORDERCLOSED || !o.Proceeds.Equal(d("151.75")) || !o.Commission.Equal(d("1.5175")) {
+		t.Errorf("unexpected order %+v %v", o, err)
+	}
+
+	balances, err := b.GetBalances()
+	if err != nil || len(balances) != 2 || !balances[0].Total.Equal(d("2.5")) || !balances[1].Total.Equal(d("846.7325")) {
+		t.Errorf("unexpected balances %+v %v", balances, err)
+	}
+
+	if _, err := b.CancelOrder(o.ID); !errors.As(err, &apiErr) || apiErr.Code != "ORDER_NOT_OPEN" {
+		t.Errorf("closed order cancelled: %v", err)
+	}
+
+	_, err = b.NewOrder(bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "SELL", Type: "LIMIT",
+		Quantity: "3", Limit: "200", TimeInForce: "GOOD_TIL_CANCELLED"})
+	if !errors.As(err, &apiErr) || apiErr.Code != "INSUFFICIENT_FUNDS" {
+		t.Errorf("order above balance accepted: %v", err)
+	}
+
+	if open, err := b.GetOpenOrders(""); err != nil || len(open) != 0 {
+		t.Errorf("unexpected open orders %+v %v", open, err)
+	}
+	if closed, err := b.GetOrderHistory("BTC-USD"); err != nil || len(closed) != 1 {
+		t.Errorf("unexpected closed orders %+v %v", closed, err)
+	}
+}
+
+func TestServerAuthAndFaults(t *testing.T) {
+	s := newTestServer(t)
+
+	bad := bittrex.New("key", "wrong")
+	bad.SetAPIBase(s.URL)
+
+	var apiErr *bittrex.APIError
+	if _, err := bad.GetBalances(); !errors.As(err, &apiErr) || apiErr.Code != "INVALID_SIGNATURE" {
+		t.Errorf("bad signature accepted: %v", err)
+	}
+
+	b := s.Client()
+
+	s.RateLimit(1)
+	if _, err := b.GetMarkets(); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("rate limit not injected: %v", err)
+	}
+	if _, err := b.GetMarkets(); err != nil {
+		t.Errorf("rate limit outlived its count: %v", err)
+	}
+
+	s.Inject(Fault{Method: "GET", Path: "balances", Latency: 50 * time.Millisecond, Times: 1})
+	start := time.Now()
+	if _, err := b.GetBalances(); err != nil || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("latency not injected: %v", err)
+	}
+}
+
+func TestServerOrderHistoryPages(t *testing.T) {
+	s := newTestServer(t)
+	b := s.Client()
+
+	g, err := bittrex.NewClientOrderIDGenerator("mm", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unfilled IOC orders close right away, the oldest is tagged
+	var first string
+	for i := 0; i < bittrex.MaxPageSize+5; i++ {
+		req := bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+			Quantity: "0.01", Limit: "50", TimeInForce: "IMMEDIATE_OR_CANCEL"}
+		if i == 0 {
+			first = g.Next()
+			req.ClientOrderID = first
+		}
+		if _, err := b.NewOrder(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := b.GetOrderHistoryPage("btc-usd", bittrex.HistoryPage{PageSize: 3})
+	if err != nil || len(page) != 3 {
+		t.Fatalf("unexpected page %+v %v", page, err)
+	}
+
+	next, err := b.GetOrderHistoryPage("btc-usd", bittrex.HistoryPage{PageSize: 3, NextPageToken: page[2].ID})
+	if err != nil || len(next) != 3 || next[0].ID == page[2].ID {
+		t.Errorf("unexpected next page %+v %v", next, err)
+	}
+
+	prev, err := b.GetOrderHistoryPage("btc-usd", bittrex.HistoryPage{PageSize: 2, PreviousPageToken: next[0].ID})
+	if err != nil || len(prev) != 2 || prev[0].ID != page[1].ID || prev[1].ID != page[2].ID {
+		t.Errorf("unexpected previous page %+v %v", prev, err)
+	}
+
+	var apiErr *bittrex.APIError
+	if _, err := b.GetOrderHistoryPage("", bittrex.HistoryPage{PageSize: 500}); !errors.As(err, &apiErr) || apiErr.Code != "INVALID_PAGE_SIZE" {
+		t.Errorf("oversized page accepted: %v", err)
+	}
+
+	o, err := b.GetClosedOrderByClientOrderID("btc-usd", first)
+	if err != nil || o.ClientOrderID != first {
+		t.Errorf("order beyond the first page not found: %+v %v", o, err)
+	}
+
+	if tagged, err := b.GetOrderHistoryByTag("btc-usd", "mm"); err != nil || len(tagged) != 1 {
+		t.Errorf("unexpected tagged orders %+v %v", tagged, err)
+	}
+
+	if _, err := b.GetClosedOrderByClientOrderID("btc-usd", g.Next()); err != bittrex.ErrOrderNotFound {
+		t.Errorf("unknown order found: %v", err)
+	}
+}
+
+func TestServerMatchesPaperTrader(t *testing.T) {
+	s := newTestServer(t)
+	s.SetFees(d("0.001"), d("0.002"))
+	b := s.Client()
+
+	p := b.NewPaperTrader(map[string]decimal.Decimal{"USD": d("1000"), "BTC": d("1")})
+	p.MakerFee, p.TakerFee = d("0.001"), d("0.002")
+	p.Update(bittrex.OrderBook{MarketSymbol: "BTC-USD",
+		BidDeltas: []bittrex.OrderDelta{{Rate: d("99"), Quantity: d("1")}, {Rate: d("98"), Quantity: d("2")}},
+		AskDeltas: []bittrex.OrderDelta{{Rate: d("101"), Quantity: d("1")}, {Rate: d("102"), Quantity: d("2")}}})
+
+	req := bittrex.NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+		Quantity: "1.5", Limit: "101.5", TimeInForce: "GOOD_TIL_CANCELLED"}
+
+	var ids [2]string
+	for i, trader := range []bittrex.Trading{b, p} {
+		r, err := trader.NewOrder(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var o bittrex.Order
+		json.Unmarshal(r, &o)
+		ids[i] = o.ID
+	}
+
+	asks := []bittrex.OrderDelta{{Rate: d("100"), Quantity: d("5")}}
+	s.SetOrderBook("BTC-USD", nil, asks)
+	p.Update(bittrex.OrderBook{MarketSymbol: "BTC-USD", AskDeltas: asks})
+
+	served, err := b.GetOrder(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	simulated, _ := p.GetOrder(ids[1])
+
+	if served.Status != bittrex.ORDERCLOSED || !served.FillQuantity.Equal(simulated.FillQuantity) ||
+		!served.Proceeds.Equal(simulated.Proceeds) || !served.Commission.Equal(simulated.Commission) {
+		t.Errorf("server filled %+v, paper trader %+v", served, simulated)
+	}
+
+	servedBalances, _ := b.GetBalances()
+	simulatedBalances, _ := p.GetBalances()
+	for i := range servedBalances {
+		if !servedBalances[i].Total.Equal(simulatedBalances[i].Total) {
+			t.Errorf("server balance %+v, paper trader %+v", servedBalances[i], simulatedBalances[i])
+		}
+	}
+}
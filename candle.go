@@ -2,16 +2,30 @@ package bittrex
 
 import "github.com/shopspring/decimal"
 
-type candle struct {
-	TimeStamp  candleTime      `json:"T"`
-	Open       decimal.Decimal `json:"O"`
-	Close      decimal.Decimal `json:"C"`
-	High       decimal.Decimal `json:"H"`
-	Low        decimal.Decimal `json:"L"`
-	Volume     decimal.Decimal `json:"V"`
-	BaseVolume decimal.Decimal `json:"BV"`
+// CANDLEINTERVALS variable
+var CANDLEINTERVALS = map[string]bool{
+	"MINUTE_1": true,
+	"MINUTE_5": true,
+	"HOUR_1":   true,
+	"DAY_1":    true,
 }
 
-type newCandles struct {
-	Ticks []candle `json:"ticks"`
+// Candle struct
+type Candle struct {
+	StartsAt    jTime           `json:"startsAt"`
+	Open        decimal.Decimal `json:"open"`
+	High        decimal.Decimal `json:"high"`
+	Low         decimal.Decimal `json:"low"`
+	Close       decimal.Decimal `json:"close"`
+	Volume      decimal.Decimal `json:"volume"`
+	QuoteVolume decimal.Decimal `json:"quoteVolume"`
+}
+
+// CandleUpdate is a candle stream message. Candles seeded from the REST API
+// have a zero Sequence.
+type CandleUpdate struct {
+	Sequence     int    `json:"sequence"`
+	MarketSymbol string `json:"marketSymbol"`
+	Interval     string `json:"interval"`
+	Delta        Candle `json:"delta"`
 }
@@ -1,6 +1,7 @@
 package bittrex
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha512"
 	"encoding/hex"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,11 +23,16 @@ type Client struct {
 	httpClient  *http.Client
 	httpTimeout time.Duration
 	debug       bool
+	apiBase     string
+
+	hooksMu       sync.RWMutex
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
 }
 
 // NewClient return a new Bittrex HTTP client
 func NewClient(apiKey, apiSecret string) (c *Client) {
-	return &Client{apiKey, apiSecret, &http.Client{}, 1 * time.Second, false}
+	return &Client{apiKey: apiKey, apiSecret: apiSecret, httpClient: &http.Client{}, httpTimeout: 1 * time.Second}
 }
 
 // NewClientWithCustomHTTPConfig returns a new Bittrex HTTP client using the predefined http client
@@ -34,15 +41,37 @@ func NewClientWithCustomHTTPConfig(apiKey, apiSecret string, httpClient *http.Cl
 	if timeout <= 0 {
 		timeout = 30 * time.Second
 	}
-	return &Client{apiKey, apiSecret, httpClient, timeout, false}
+	return &Client{apiKey: apiKey, apiSecret: apiSecret, httpClient: httpClient, httpTimeout: timeout}
 }
 
 // NewClientWithCustomTimeout returns a new Bittrex HTTP client with custom timeout
 func NewClientWithCustomTimeout(apiKey, apiSecret string, timeout time.Duration) (c *Client) {
-	return &Client{apiKey, apiSecret, &http.Client{}, timeout, false}
+	return &Client{apiKey: apiKey, apiSecret: apiSecret, httpClient: &http.Client{}, httpTimeout: timeout}
+}
+
+// SetAPIBase sets the REST API base URL, APIBASE by default
+func (c *Client) SetAPIBase(base string) {
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	c.apiBase = base
 }
 
-func (c Client) dumpRequest(r *http.Request) {
+// url returns the full URL of resource
+func (c *Client) url(resource string) string {
+	if strings.HasPrefix(resource, "http") {
+		return resource
+	}
+
+	base := c.apiBase
+	if base == "" {
+		base = APIBASE
+	}
+
+	return fmt.Sprintf("%s%s/%s", base, APIVERSION, resource)
+}
+
+func (c *Client) dumpRequest(r *http.Request) {
 	if r == nil {
 		log.Print("dumpReq ok: <nil>")
 		return
@@ -55,7 +84,7 @@ func (c Client) dumpRequest(r *http.Request) {
 	}
 }
 
-func (c Client) dumpResponse(r *http.Response) {
+func (c *Client) dumpResponse(r *http.Response) {
 	if r == nil {
 		log.Print("dumpResponse ok: <nil>")
 		return
@@ -95,17 +124,58 @@ func (c *Client) doTimeoutRequest(timer *time.Timer, req *http.Request) (*http.R
 	}
 }
 
-// do prepare and process HTTP request to Bittrex API
-func (c *Client) do(method string, resource string, payload string, authNeeded bool) (response []byte, err error) {
-	connectTimer := time.NewTimer(c.httpTimeout)
+// roundTrip sends req through the hook chain and reads the whole response body.
+// The returned response carries a rewound copy of the body.
+func (c *Client) roundTrip(endpoint string, req *http.Request) (resp *http.Response, body []byte, err error) {
+	c.beforeRequest(req)
 
-	var rawurl string
-	if strings.HasPrefix(resource, "http") {
-		rawurl = resource
-	} else {
-		rawurl = fmt.Sprintf("%s%s/%s", APIBASE, APIVERSION, resource)
+	info := ResponseInfo{Request: req, Method: req.Method, Endpoint: endpoint}
+	start := time.Now()
+
+	defer func() {
+		info.Duration = time.Since(start)
+		info.Err = err
+		c.afterResponse(info)
+	}()
+
+	resp, err = c.doTimeoutRequest(time.NewTimer(c.httpTimeout), req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer resp.Body.Close()
+	info.StatusCode = resp.StatusCode
+
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if !statusOK(req.Method, resp.StatusCode) {
+		info.APIError = newAPIError(resp, body)
+		err = info.APIError
+	}
+
+	return resp, body, err
+}
+
+func statusOK(method string, status int) bool {
+	switch method {
+	case "POST":
+		return status == 201
+	case "GET", "DELETE":
+		return status == 200
 	}
 
+	return true
+}
+
+// do prepare and process HTTP request to Bittrex API.
+// endpoint is the resource template reported to the response hooks.
+func (c *Client) do(method string, endpoint string, resource string, payload string, authNeeded bool) (response []byte, err error) {
+	rawurl := c.url(resource)
+
 	req, err := http.NewRequest(method, rawurl, strings.NewReader(payload))
 	if err != nil {
 		return
@@ -141,38 +211,14 @@ func (c *Client) do(method string, resource string, payload string, authNeeded b
 		req.Header.Add("Api-Signature", sig)
 	}
 
-	resp, err := c.doTimeoutRequest(connectTimer, req)
-	if err != nil {
-		return
-	}
-
-	defer resp.Body.Close()
-	response, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 201 && method == "POST" {
-		err = errors.New(resp.Status)
-	}
-
-	if resp.StatusCode != 200 && (method == "GET" || method == "DELETE") {
-		err = errors.New(resp.Status)
-	}
+	_, response, err = c.roundTrip(endpoint, req)
 
 	return response, err
 }
 
 // do2 prepare and process HTTP request to Bittrex API
-func (c *Client) do2(resource string) (*http.Response, error) {
-	connectTimer := time.NewTimer(c.httpTimeout)
-
-	var rawurl string
-	if strings.HasPrefix(resource, "http") {
-		rawurl = resource
-	} else {
-		rawurl = fmt.Sprintf("%s%s/%s", APIBASE, APIVERSION, resource)
-	}
+func (c *Client) do2(endpoint string, resource string) (*http.Response, error) {
+	rawurl := c.url(resource)
 
 	req, err := http.NewRequest("GET", rawurl, nil)
 	if err != nil {
@@ -181,15 +227,10 @@ func (c *Client) do2(resource string) (*http.Response, error) {
 
 	req.Header.Add("Accept", "application/json")
 
-	resp, err := c.doTimeoutRequest(connectTimer, req)
+	resp, _, err := c.roundTrip(endpoint, req)
 	if err != nil {
 		return nil, err
-
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, errors.New(resp.Status)
 	}
 
-	return resp, err
+	return resp, nil
 }
@@ -0,0 +1,203 @@
+package bittrex
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client order IDs made by ClientOrderIDGenerator are version 8 UUIDs laid out as
+//
+//	bytes 0-5   strategy tag, ASCII padded with zeros
+//	bytes 6-7   version nibble and 12 bit instance
+//	bytes 8-15  variant bits and 62 bit counter
+const (
+	clientOrderIDTagLen      = 6
+	clientOrderIDMaxInstance = 1<<12 - 1
+	clientOrderIDCounter     = 1<<62 - 1
+)
+
+// ErrOrderNotFound is returned when an order lookup has no match
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrUntaggedClientOrderID is returned when parsing a client order ID not made by ClientOrderIDGenerator
+var ErrUntaggedClientOrderID = errors.New("client order ID is not tagged")
+
+// ClientOrderID is a decoded client order ID
+type ClientOrderID struct {
+	Tag      string
+	Instance uint16
+	Counter  uint64
+}
+
+// String encodes the ID as a UUID
+func (id ClientOrderID) String() string {
+	var u uuid.UUID
+
+	copy(u[:clientOrderIDTagLen], id.Tag)
+	u[6] = 0x80 | byte(id.Instance>>8)&0x0f
+	u[7] = byte(id.Instance)
+
+	c := id.Counter & clientOrderIDCounter
+	for i := 15; i >= 8; i-- {
+		u[i] = byte(c)
+		c >>= 8
+	}
+	u[8] = 0x80 | u[8]&0x3f
+
+	return u.String()
+}
+
+// ParseClientOrderID decodes an ID made by ClientOrderIDGenerator
+func ParseClientOrderID(s string) (id ClientOrderID, err error) {
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return
+	}
+
+	if u.Version() != 8 || u.Variant() != uuid.RFC4122 {
+		return id, ErrUntaggedClientOrderID
+	}
+
+	tag := u[:clientOrderIDTagLen]
+	if i := strings.IndexByte(string(tag), 0); i >= 0 {
+		tag = tag[:i]
+	}
+	for _, c := range tag {
+		if c < 0x21 || c > 0x7e {
+			return id, ErrUntaggedClientOrderID
+		}
+	}
+
+	id.Tag = string(tag)
+	id.Instance = uint16(u[6]&0x0f)<<8 | uint16(u[7])
+	for _, c := range u[8:] {
+		id.Counter = id.Counter<<8 | uint64(c)
+	}
+	id.Counter &= clientOrderIDCounter
+
+	return id, nil
+}
+
+// ClientOrderIDGenerator makes client order IDs carrying a strategy tag and
+// a counter increasing with every ID. The counter starts from the current
+// time, so IDs stay increasing across restarts of a process.
+//
+// Placing an order again with the ID of the first attempt makes retries safe,
+// the exchange rejects a second order with the same client order ID.
+type ClientOrderIDGenerator struct {
+	tag      string
+	instance uint16
+	counter  uint64
+}
+
+// NewClientOrderIDGenerator returns a generator for strategy tag, at most six
+// printable ASCII characters. instance, below 4096, tells apart processes
+// running the same strategy.
+func NewClientOrderIDGenerator(tag string, instance uint16) (*ClientOrderIDGenerator, error) {
+	if tag == "" || len(tag) > clientOrderIDTagLen {
+		return nil, errors.New("tag must have 1 to 6 characters")
+	}
+
+	for _, c := range []byte(tag) {
+		if c < 0x21 || c > 0x7e {
+			return nil, errors.New("tag must be printable ASCII")
+		}
+	}
+
+	if instance > clientOrderIDMaxInstance {
+		return nil, errors.New("instance must be below 4096")
+	}
+
+	return &ClientOrderIDGenerator{
+		tag:      tag,
+		instance: instance,
+		counter:  uint64(time.Now().UnixNano()) & clientOrderIDCounter,
+	}, nil
+}
+
+// Next returns a new client order ID
+func (g *ClientOrderIDGenerator) Next() string {
+	return ClientOrderID{
+		Tag:      g.tag,
+		Instance: g.instance,
+		Counter:  atomic.AddUint64(&g.counter, 1),
+	}.String()
+}
+
+// OrdersByTag returns the orders whose client order ID carries tag
+func OrdersByTag(orders []Order, tag string) (tagged []Order) {
+	for _, o := range orders {
+		if id, err := ParseClientOrderID(o.ClientOrderID); err == nil && id.Tag == tag {
+			tagged = append(tagged, o)
+		}
+	}
+
+	return
+}
+
+// GetOrderHistoryByTag returns the closed orders of market placed by strategy tag.
+// It pages through the whole order history, one request per MaxPageSize orders.
+func (b *Bittrex) GetOrderHistoryByTag(market, tag string) (tagged []Order, err error) {
+	err = b.eachClosedOrder(market, func(o Order) bool {
+		if id, err := ParseClientOrderID(o.ClientOrderID); err == nil && id.Tag == tag {
+			tagged = append(tagged, o)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tagged, nil
+}
+
+// GetClosedOrderByClientOrderID looks up the closed order of market with clientOrderID.
+// It pages through the order history until the order is found and returns
+// ErrOrderNotFound when the history has none.
+func (b *Bittrex) GetClosedOrderByClientOrderID(market, clientOrderID string) (order Order, err error) {
+	found := false
+
+	err = b.eachClosedOrder(market, func(o Order) bool {
+		if o.ClientOrderID == clientOrderID {
+			order, found = o, true
+		}
+		return !found
+	})
+	if err != nil {
+		return Order{}, err
+	}
+
+	if !found {
+		return Order{}, ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+// eachClosedOrder calls f with the closed orders of market, newest first, until
+// f returns false or the history ends
+func (b *Bittrex) eachClosedOrder(market string, f func(o Order) bool) error {
+	page := HistoryPage{PageSize: MaxPageSize}
+
+	for {
+		orders, err := b.GetOrderHistoryPage(market, page)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			if !f(o) {
+				return nil
+			}
+		}
+
+		if len(orders) < page.PageSize {
+			return nil
+		}
+		page.NextPageToken = orders[len(orders)-1].ID
+	}
+}
@@ -0,0 +1,51 @@
+package bittrex
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestClientOrderID(t *testing.T) {
+	g, err := NewClientOrderIDGenerator("mm-1", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := g.Next(), g.Next()
+	if _, err := uuid.Parse(first); err != nil {
+		t.Fatalf("not a UUID: %s", first)
+	}
+
+	a, err := ParseClientOrderID(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ParseClientOrderID(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Tag != "mm-1" || a.Instance != 42 || b.Counter != a.Counter+1 {
+		t.Errorf("unexpected ids %+v %+v", a, b)
+	}
+
+	if _, err := ParseClientOrderID(uuid.New().String()); err != ErrUntaggedClientOrderID {
+		t.Errorf("random UUID parsed: %v", err)
+	}
+
+	if _, err := NewClientOrderIDGenerator("toolong", 0); err == nil {
+		t.Error("long tag accepted")
+	}
+
+	orders := []Order{{ClientOrderID: first}, {ClientOrderID: uuid.New().String()}, {}}
+	if tagged := OrdersByTag(orders, "mm-1"); len(tagged) != 1 || tagged[0].ClientOrderID != first {
+		t.Errorf("unexpected tagged orders %+v", tagged)
+	}
+
+	replaced, err := ParseClientOrderID(replaceClientOrderID(Order{ID: "o1", ClientOrderID: first}))
+	if err != nil || replaced.Tag != "mm-1" || replaced.Instance != 42 {
+		t.Errorf("replacement lost tag: %+v %v", replaced, err)
+	}
+}
@@ -0,0 +1,45 @@
+package bittrex
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientHooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"INSUFFICIENT_FUNDS"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClientWithCustomTimeout("key", "secret", 5*time.Second)
+
+	var signed bool
+	var info ResponseInfo
+
+	c.OnRequest(func(req *http.Request) {
+		signed = req.Header.Get("Api-Signature") != ""
+	})
+	c.OnResponse(func(i ResponseInfo) {
+		info = i
+	})
+
+	_, err := c.do("POST", "orders", srv.URL+"/orders", "{}", true)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !signed {
+		t.Error("request hook did not see a signed request")
+	}
+
+	if info.Endpoint != "orders" || info.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected response info: %+v", info)
+	}
+
+	if info.APIError == nil || info.APIError.Code != "INSUFFICIENT_FUNDS" || info.Err != error(info.APIError) {
+		t.Errorf("unexpected api error: %+v", info.APIError)
+	}
+}
@@ -0,0 +1,31 @@
+package bittrex
+
+import "github.com/shopspring/decimal"
+
+// ConditionalOrder struct
+type ConditionalOrder struct {
+	ID                       string          `json:"id"`
+	MarketSymbol             string          `json:"marketSymbol"`
+	Operand                  string          `json:"operand"`
+	TriggerPrice             decimal.Decimal `json:"triggerPrice"`
+	TrailingStopPercent      decimal.Decimal `json:"trailingStopPercent"`
+	CreatedOrderID           string          `json:"createdOrderId"`
+	OrderToCreate            *NewOrder       `json:"orderToCreate"`
+	ClientConditionalOrderID string          `json:"clientConditionalOrderId"`
+	Status                   string          `json:"status"`
+	OrderCreationErrorCode   string          `json:"orderCreationErrorCode"`
+	CreatedAt                jTime           `json:"createdAt"`
+	UpdatedAt                *jTime          `json:"updatedAt"`
+	ClosedAt                 *jTime          `json:"closedAt"`
+	OrderToCancel            *struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"orderToCancel"`
+}
+
+// ConditionalOrderUpdate is a conditional order stream message
+type ConditionalOrderUpdate struct {
+	AccountID string           `json:"accountId"`
+	Sequence  int              `json:"sequence"`
+	Delta     ConditionalOrder `json:"delta"`
+}
@@ -0,0 +1,74 @@
+package bittrex
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// DecodeError is returned by DecodeMessage with the step which failed
+type DecodeError struct {
+	// Op is one of "base64", "inflate" or "unmarshal"
+	Op  string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "decode message: " + e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+type decoder struct {
+	compressed []byte
+	src        bytes.Reader
+	inflater   io.ReadCloser
+	out        bytes.Buffer
+}
+
+var decoders = sync.Pool{
+	New: func() interface{} { return &decoder{} },
+}
+
+// DecodeMessage decodes a hub message argument, a base64 encoded raw deflate
+// stream of JSON, into v. It can be used to decode captured frames offline.
+func DecodeMessage(raw json.RawMessage, v interface{}) error {
+	d := decoders.Get().(*decoder)
+	defer decoders.Put(d)
+
+	src := bytes.Trim(raw, `"`)
+
+	n := base64.StdEncoding.DecodedLen(len(src))
+	if cap(d.compressed) < n {
+		d.compressed = make([]byte, n)
+	}
+
+	n, err := base64.StdEncoding.Decode(d.compressed[:n], src)
+	if err != nil {
+		return &DecodeError{Op: "base64", Err: err}
+	}
+
+	d.src.Reset(d.compressed[:n])
+	if d.inflater == nil {
+		d.inflater = flate.NewReader(&d.src)
+	} else if err := d.inflater.(flate.Resetter).Reset(&d.src, nil); err != nil {
+		return &DecodeError{Op: "inflate", Err: err}
+	}
+
+	d.out.Reset()
+	if _, err := d.out.ReadFrom(d.inflater); err != nil {
+		return &DecodeError{Op: "inflate", Err: err}
+	}
+
+	if err := json.Unmarshal(d.out.Bytes(), v); err != nil {
+		return &DecodeError{Op: "unmarshal", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package bittrex
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func encodeMessage(t *testing.T, payload string) json.RawMessage {
+	var buf bytes.Buffer
+
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(payload))
+	w.Close()
+
+	return json.RawMessage(`"` + base64.StdEncoding.EncodeToString(buf.Bytes()) + `"`)
+}
+
+func TestDecodeMessage(t *testing.T) {
+	var book OrderBook
+	if err := DecodeMessage(encodeMessage(t, `{"marketSymbol":"BTC-USD","depth":25,"sequence":3}`), &book); err != nil {
+		t.Fatal(err)
+	}
+
+	if book.MarketSymbol != "BTC-USD" || book.Sequence != 3 {
+		t.Errorf("unexpected orderbook %+v", book)
+	}
+
+	for raw, op := range map[string]string{
+		`"%%%"`: "base64",
+		`"` + base64.StdEncoding.EncodeToString([]byte("not deflate")) + `"`: "inflate",
+		string(encodeMessage(t, `{"sequence":"x"}`)):                         "unmarshal",
+	} {
+		var derr *DecodeError
+		err := DecodeMessage(json.RawMessage(raw), &book)
+		if !errors.As(err, &derr) || derr.Op != op {
+			t.Errorf("%s: got %v, want %s error", raw, err, op)
+		}
+	}
+}
@@ -4,11 +4,22 @@ import "github.com/shopspring/decimal"
 
 //Deposit struct
 type Deposit struct {
-	ID            int64           `json:"Id"`
-	Amount        decimal.Decimal `json:"Amount"`
-	Currency      string          `json:"Currency"`
-	Confirmations int             `json:"Confirmations"`
-	LastUpdated   jTime           `json:"LastUpdated"`
-	TxID          string          `json:"TxId"`
-	CryptoAddress string          `json:"CryptoAddress"`
+	ID               string          `json:"id"`
+	CurrencySymbol   string          `json:"currencySymbol"`
+	Quantity         decimal.Decimal `json:"quantity"`
+	CryptoAddress    string          `json:"cryptoAddress"`
+	CryptoAddressTag string          `json:"cryptoAddressTag"`
+	TxID             string          `json:"txId"`
+	Confirmations    int             `json:"confirmations"`
+	UpdatedAt        *jTime          `json:"updatedAt"`
+	CompletedAt      *jTime          `json:"completedAt"`
+	Status           string          `json:"status"`
+	Source           string          `json:"source"`
+}
+
+// DepositUpdate is a deposit stream message
+type DepositUpdate struct {
+	AccountID string  `json:"accountId"`
+	Sequence  int     `json:"sequence"`
+	Delta     Deposit `json:"delta"`
 }
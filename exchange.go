@@ -0,0 +1,65 @@
+package bittrex
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarketData is the public market data of the REST API
+type MarketData interface {
+	GetMarkets() ([]Market, error)
+	GetTicker(market string) (Ticker, error)
+	GetTickers() ([]Ticker, error)
+	GetMarketSummaries() ([]MarketSummary, error)
+	GetMarketSummary(market string) (MarketSummary, error)
+	GetTrades(market string) ([]Trade, error)
+	GetCandles(market, interval string) ([]Candle, error)
+	GetOrderBook(book *OrderBook) error
+}
+
+// Trading places, follows and cancels orders
+type Trading interface {
+	NewOrder(order NewOrder) ([]byte, error)
+	CancelOrder(orderID string) ([]byte, error)
+	ReplaceOrder(ctx context.Context, orderID string, newPrice, newQty decimal.Decimal) (ReplaceResult, error)
+	WaitForOrder(ctx context.Context, orderID string, condition OrderCondition) (Order, error)
+	GetOpenOrders(market string) ([]Order, error)
+	GetOrder(orderID string) (Order, error)
+	GetOrderHistory(market string) ([]Order, error)
+	GetOrderHistoryPage(market string, page HistoryPage) ([]Order, error)
+	GetOrderHistoryByTag(market, tag string) ([]Order, error)
+	GetClosedOrderByClientOrderID(market, clientOrderID string) (Order, error)
+}
+
+// Account is the account state of the REST API
+type Account interface {
+	GetBalances() ([]Balance, error)
+}
+
+// Streaming is the typed websocket subscriptions
+type Streaming interface {
+	SubscribeTickerUpdates(ctx context.Context, ticker chan<- Ticker, markets ...string) error
+	SubscribeOrderUpdates(ctx context.Context, dataCh chan<- OrderUpdate) error
+	SubscribeOrderbookUpdates(ctx context.Context, orderbook chan<- OrderBook, depth int, markets ...string) error
+	SubscribeTrades(ctx context.Context, trades chan<- TradeUpdate, markets ...string) error
+	SubscribeCandles(ctx context.Context, market, interval string, candles chan<- CandleUpdate, seed bool) error
+	SubscribeBalanceUpdates(ctx context.Context, dataCh chan<- BalanceUpdate) error
+	SubscribeDepositUpdates(ctx context.Context, dataCh chan<- DepositUpdate) error
+	SubscribeExecutionUpdates(ctx context.Context, dataCh chan<- ExecutionUpdate) error
+	SubscribeConditionalOrderUpdates(ctx context.Context, dataCh chan<- ConditionalOrderUpdate) error
+	SubscribeMarketSummaries(ctx context.Context, dataCh chan<- MarketSummariesUpdate) error
+	SubscribeMarketSummary(ctx context.Context, dataCh chan<- MarketSummary, markets ...string) error
+	SubscribeTickers(ctx context.Context, dataCh chan<- TickersUpdate) error
+}
+
+// Exchange is everything a strategy needs from Bittrex. It is implemented
+// by *Bittrex and by bittrexmock.Exchange in tests.
+type Exchange interface {
+	MarketData
+	Trading
+	Account
+	Streaming
+}
+
+var _ Exchange = (*Bittrex)(nil)
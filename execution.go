@@ -0,0 +1,22 @@
+package bittrex
+
+import "github.com/shopspring/decimal"
+
+// Execution is a fill of one of the account orders
+type Execution struct {
+	ID           string          `json:"id"`
+	MarketSymbol string          `json:"marketSymbol"`
+	ExecutedAt   jTime           `json:"executedAt"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Rate         decimal.Decimal `json:"rate"`
+	OrderID      string          `json:"orderId"`
+	Commission   decimal.Decimal `json:"commission"`
+	IsTaker      bool            `json:"isTaker"`
+}
+
+// ExecutionUpdate is an execution stream message
+type ExecutionUpdate struct {
+	AccountID string      `json:"accountId"`
+	Sequence  int         `json:"sequence"`
+	Deltas    []Execution `json:"deltas"`
+}
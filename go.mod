@@ -4,7 +4,7 @@ go 1.16
 
 require (
 	github.com/google/uuid v1.2.0
-	github.com/gorilla/websocket v1.4.2 // indirect
+	github.com/gorilla/websocket v1.4.2
 	github.com/shopspring/decimal v1.2.0
 	github.com/thebotguys/signalr v0.0.0-20190119054324-787ebe6729fc
 )
@@ -0,0 +1,99 @@
+package bittrex
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestHook is called with the signed request right before it is sent.
+// Headers added here are not part of the request signature.
+type RequestHook func(req *http.Request)
+
+// ResponseHook is called once a REST call has completed, successfully or not.
+type ResponseHook func(info ResponseInfo)
+
+// ResponseInfo describes a completed REST call
+type ResponseInfo struct {
+	Request *http.Request
+	// Method is the HTTP method of the call
+	Method string
+	// Endpoint is the resource template, e.g. "markets/{marketSymbol}/ticker"
+	Endpoint string
+	// StatusCode is zero when no response was received
+	StatusCode int
+	Duration   time.Duration
+	// Err is the error returned to the caller, if any
+	Err error
+	// APIError is the parsed Bittrex error body for non-success statuses
+	APIError *APIError
+}
+
+// OnRequest appends h to the hooks called before every REST request
+func (c *Client) OnRequest(h RequestHook) {
+	c.hooksMu.Lock()
+	c.requestHooks = append(c.requestHooks, h)
+	c.hooksMu.Unlock()
+}
+
+// OnResponse appends h to the hooks called after every REST request
+func (c *Client) OnResponse(h ResponseHook) {
+	c.hooksMu.Lock()
+	c.responseHooks = append(c.responseHooks, h)
+	c.hooksMu.Unlock()
+}
+
+func (c *Client) beforeRequest(req *http.Request) {
+	c.hooksMu.RLock()
+	hooks := c.requestHooks
+	c.hooksMu.RUnlock()
+
+	for _, h := range hooks {
+		h(req)
+	}
+}
+
+func (c *Client) afterResponse(info ResponseInfo) {
+	c.hooksMu.RLock()
+	hooks := c.responseHooks
+	c.hooksMu.RUnlock()
+
+	for _, h := range hooks {
+		h(info)
+	}
+}
+
+// OnStreamMessage appends h to the handlers called with every decoded message
+// received by the streams of b, including those of the Subscribe methods
+func (b *Bittrex) OnStreamMessage(h StreamHandler) {
+	b.hooksMu.Lock()
+	b.streamHooks = append(b.streamHooks, h)
+	b.hooksMu.Unlock()
+}
+
+func (b *Bittrex) streamMessage(m StreamMessage) {
+	b.hooksMu.RLock()
+	hooks := b.streamHooks
+	b.hooksMu.RUnlock()
+
+	for _, h := range hooks {
+		h(m)
+	}
+}
+
+// OnStreamError appends h to the handlers called with the errors of the
+// streams of b, including the decoding errors of the Subscribe methods
+func (b *Bittrex) OnStreamError(h func(err error)) {
+	b.hooksMu.Lock()
+	b.streamErrorHooks = append(b.streamErrorHooks, h)
+	b.hooksMu.Unlock()
+}
+
+func (b *Bittrex) streamError(err error) {
+	b.hooksMu.RLock()
+	hooks := b.streamErrorHooks
+	b.hooksMu.RUnlock()
+
+	for _, h := range hooks {
+		h(err)
+	}
+}
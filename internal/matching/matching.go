@@ -0,0 +1,507 @@
+// Package matching is the order matching engine shared by the fake server of
+// bittrextest and PaperTrader. It validates orders the way the v3 API does,
+// fills them against order book levels and settles the balances of one
+// account. It is not safe for concurrent use.
+package matching
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Order directions, types, time in force and statuses of the v3 API
+const (
+	Buy    = "BUY"
+	Sell   = "SELL"
+	Limit  = "LIMIT"
+	Market = "MARKET"
+
+	GoodTilCancelled  = "GOOD_TIL_CANCELLED"
+	ImmediateOrCancel = "IMMEDIATE_OR_CANCEL"
+	FillOrKill        = "FILL_OR_KILL"
+	PostOnly          = "POST_ONLY_GOOD_TIL_CANCELLED"
+
+	Open   = "OPEN"
+	Closed = "CLOSED"
+)
+
+// Error rejects an order with the HTTP status and error code of the API
+type Error struct {
+	Status int
+	Code   string
+}
+
+func (e *Error) Error() string {
+	return e.Code
+}
+
+func reject(status int, code string) *Error {
+	return &Error{Status: status, Code: code}
+}
+
+// Level is a price level of a book
+type Level struct {
+	Rate     decimal.Decimal
+	Quantity decimal.Decimal
+}
+
+// Book is the liquidity of a market, levels are sorted from the best price
+type Book struct {
+	Bids []Level
+	Asks []Level
+	// Sequence is bumped by every change of the levels
+	Sequence int
+	// Last is the rate of the last fill
+	Last decimal.Decimal
+}
+
+// Symbol is a market orders are placed in
+type Symbol struct {
+	Symbol       string
+	Base         string
+	Quote        string
+	MinTradeSize decimal.Decimal
+}
+
+// Request is a new order as the API receives it
+type Request struct {
+	Direction     string
+	Type          string
+	Quantity      string
+	Limit         string
+	TimeInForce   string
+	ClientOrderID string
+}
+
+// Order is an order of the account
+type Order struct {
+	ID            string
+	MarketSymbol  string
+	Direction     string
+	Type          string
+	Quantity      decimal.Decimal
+	Limit         decimal.Decimal
+	TimeInForce   string
+	ClientOrderID string
+	FillQuantity  decimal.Decimal
+	Commission    decimal.Decimal
+	Proceeds      decimal.Decimal
+	Status        string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ClosedAt      time.Time
+	// Sequence is left to the owner of the engine
+	Sequence int
+}
+
+// Remaining returns the quantity not filled yet
+func (o *Order) Remaining() decimal.Decimal {
+	return o.Quantity.Sub(o.FillQuantity)
+}
+
+// Engine keeps the books, balances and orders of an account. Taker fills pay
+// TakerFee and maker fills MakerFee, both as a rate of the proceeds.
+type Engine struct {
+	MakerFee decimal.Decimal
+	TakerFee decimal.Decimal
+	Balances map[string]decimal.Decimal
+	Books    map[string]*Book
+
+	orders  map[string]*Order
+	created []*Order
+	symbols map[string]Symbol
+}
+
+// New returns an Engine without books, balances nor orders
+func New() *Engine {
+	return &Engine{
+		Balances: make(map[string]decimal.Decimal),
+		Books:    make(map[string]*Book),
+		orders:   make(map[string]*Order),
+		symbols:  make(map[string]Symbol),
+	}
+}
+
+// SetBook replaces the levels of a market. Levels of the same rate are
+// merged and empty ones dropped. Open orders are not matched.
+func (e *Engine) SetBook(market string, bids, asks []Level) *Book {
+	b := e.Books[market]
+	if b == nil {
+		b = &Book{}
+		e.Books[market] = b
+	}
+
+	b.Bids = SortLevels(bids, true)
+	b.Asks = SortLevels(asks, false)
+	b.Sequence++
+
+	return b
+}
+
+// Order returns the order with id
+func (e *Engine) Order(id string) (*Order, bool) {
+	o, ok := e.orders[id]
+	return o, ok
+}
+
+// Orders returns the open or closed orders of a market, or of all markets
+// when market is empty. Open orders come oldest first, closed ones most
+// recently closed first.
+func (e *Engine) Orders(market string, open bool) []*Order {
+	var orders []*Order
+
+	for i := len(e.created) - 1; i >= 0; i-- {
+		o := e.created[i]
+		if (o.Status == Open) == open && (market == "" || o.MarketSymbol == market) {
+			orders = append(orders, o)
+		}
+	}
+
+	if open {
+		for i, j := 0, len(orders)-1; i < j; i, j = i+1, j-1 {
+			orders[i], orders[j] = orders[j], orders[i]
+		}
+		return orders
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool { return orders[i].ClosedAt.After(orders[j].ClosedAt) })
+	return orders
+}
+
+// MaxPageSize is the largest page of History
+const MaxPageSize = 200
+
+// Page selects a page of the closed orders, like the query of the API.
+// NextPageToken is the ID of the last order of the previous page and
+// PreviousPageToken the ID of the first order of the next page. A zero
+// PageSize selects 100 orders and zero dates are ignored.
+type Page struct {
+	NextPageToken     string
+	PreviousPageToken string
+	PageSize          int
+	StartDate         time.Time
+	EndDate           time.Time
+}
+
+// History returns a page of the closed orders of a market, or of all markets
+// when market is empty, most recently closed first
+func (e *Engine) History(market string, p Page) ([]*Order, error) {
+	size := p.PageSize
+	switch {
+	case size == 0:
+		size = 100
+	case size < 1 || size > MaxPageSize:
+		return nil, reject(http.StatusBadRequest, "INVALID_PAGE_SIZE")
+	}
+
+	var dated []*Order
+	for _, o := range e.Orders(market, false) {
+		if (p.StartDate.IsZero() || !o.ClosedAt.Before(p.StartDate)) && (p.EndDate.IsZero() || o.ClosedAt.Before(p.EndDate)) {
+			dated = append(dated, o)
+		}
+	}
+
+	index := func(id string) int {
+		for i, o := range dated {
+			if o.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if p.NextPageToken != "" {
+		i := index(p.NextPageToken)
+		if i < 0 {
+			return nil, reject(http.StatusBadRequest, "INVALID_PAGE_TOKEN")
+		}
+		dated = dated[i+1:]
+	} else if p.PreviousPageToken != "" {
+		i := index(p.PreviousPageToken)
+		if i < 0 {
+			return nil, reject(http.StatusBadRequest, "INVALID_PAGE_TOKEN")
+		}
+		dated = dated[:i]
+		if len(dated) > size {
+			dated = dated[len(dated)-size:]
+		}
+	}
+
+	if len(dated) > size {
+		dated = dated[:size]
+	}
+
+	return dated, nil
+}
+
+// Place validates, records and matches a new order of market m. The
+// existing order is returned along with the error of a duplicate client
+// order ID.
+func (e *Engine) Place(m Symbol, req Request) (*Order, error) {
+	if req.Direction != Buy && req.Direction != Sell {
+		return nil, reject(http.StatusBadRequest, "INVALID_DIRECTION")
+	}
+
+	if req.Type != Limit && req.Type != Market {
+		return nil, reject(http.StatusBadRequest, "INVALID_ORDER_TYPE")
+	}
+
+	qty, err := decimal.NewFromString(req.Quantity)
+	if err != nil || !qty.IsPositive() {
+		return nil, reject(http.StatusBadRequest, "INVALID_QUANTITY")
+	}
+	if qty.LessThan(m.MinTradeSize) {
+		return nil, reject(http.StatusBadRequest, "MIN_TRADE_REQUIREMENT_NOT_MET")
+	}
+
+	var rate decimal.Decimal
+	if req.Type == Limit {
+		rate, err = decimal.NewFromString(req.Limit)
+		if err != nil || !rate.IsPositive() {
+			return nil, reject(http.StatusBadRequest, "INVALID_LIMIT")
+		}
+	}
+
+	tif := req.TimeInForce
+	switch {
+	case tif == "" && req.Type == Limit:
+		tif = GoodTilCancelled
+	case tif == "":
+		tif = ImmediateOrCancel
+	case req.Type == Market && tif != ImmediateOrCancel && tif != FillOrKill:
+		return nil, reject(http.StatusBadRequest, "INVALID_TIME_IN_FORCE")
+	case tif != GoodTilCancelled && tif != ImmediateOrCancel && tif != FillOrKill && tif != PostOnly:
+		return nil, reject(http.StatusBadRequest, "INVALID_TIME_IN_FORCE")
+	}
+
+	if req.ClientOrderID != "" {
+		for _, o := range e.created {
+			if o.ClientOrderID == req.ClientOrderID {
+				return o, reject(http.StatusConflict, "DUPLICATE_CLIENT_ORDER_ID")
+			}
+		}
+	}
+
+	o := &Order{
+		ID:            uuid.New().String(),
+		MarketSymbol:  m.Symbol,
+		Direction:     req.Direction,
+		Type:          req.Type,
+		Quantity:      qty,
+		Limit:         rate,
+		TimeInForce:   tif,
+		ClientOrderID: req.ClientOrderID,
+		Status:        Open,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	e.symbols[m.Symbol] = m
+	available, cost := e.liquidity(o)
+
+	if tif == PostOnly && available.IsPositive() {
+		return nil, reject(http.StatusBadRequest, "POST_ONLY_CROSS_MARKET")
+	}
+
+	reserved := e.Reserved()
+	if o.Direction == Buy {
+		if o.Type == Limit {
+			cost = qty.Mul(rate)
+		}
+		fee := decimal.Max(e.MakerFee, e.TakerFee)
+		if cost.Add(cost.Mul(fee)).GreaterThan(e.Balances[m.Quote].Sub(reserved[m.Quote])) {
+			return nil, reject(http.StatusBadRequest, "INSUFFICIENT_FUNDS")
+		}
+	} else if qty.GreaterThan(e.Balances[m.Base].Sub(reserved[m.Base])) {
+		return nil, reject(http.StatusBadRequest, "INSUFFICIENT_FUNDS")
+	}
+
+	e.orders[o.ID] = o
+	e.created = append(e.created, o)
+
+	if tif != FillOrKill || available.GreaterThanOrEqual(qty) {
+		e.Match(o, false)
+	}
+
+	if o.Status == Open && tif != GoodTilCancelled && tif != PostOnly {
+		e.Close(o)
+	}
+
+	return o, nil
+}
+
+// liquidity returns the quantity o can take from the book, up to its
+// quantity, and its cost
+func (e *Engine) liquidity(o *Order) (qty, cost decimal.Decimal) {
+	b := e.Books[o.MarketSymbol]
+	if b == nil {
+		return
+	}
+
+	levels := b.Asks
+	if o.Direction == Sell {
+		levels = b.Bids
+	}
+
+	for _, l := range levels {
+		if !crosses(o, l.Rate) {
+			break
+		}
+
+		take := decimal.Min(l.Quantity, o.Quantity.Sub(qty))
+		qty = qty.Add(take)
+		cost = cost.Add(take.Mul(l.Rate))
+
+		if qty.Equal(o.Quantity) {
+			break
+		}
+	}
+
+	return
+}
+
+// Match fills the open order o against the book, at the level
+// rates paying TakerFee, or at its limit paying MakerFee when maker is set
+// because the book moved through a resting order. The liquidity taken is
+// removed from the book. It reports whether o was filled.
+func (e *Engine) Match(o *Order, maker bool) bool {
+	b := e.Books[o.MarketSymbol]
+	if b == nil {
+		return false
+	}
+
+	levels := &b.Asks
+	if o.Direction == Sell {
+		levels = &b.Bids
+	}
+
+	fee := e.TakerFee
+	if maker {
+		fee = e.MakerFee
+	}
+
+	filled := false
+	for len(*levels) > 0 && o.Remaining().IsPositive() {
+		l := &(*levels)[0]
+		if !crosses(o, l.Rate) {
+			break
+		}
+
+		rate := l.Rate
+		if maker {
+			rate = o.Limit
+		}
+
+		take := decimal.Min(l.Quantity, o.Remaining())
+		e.fill(o, take, rate, fee)
+		b.Last = rate
+		filled = true
+
+		l.Quantity = l.Quantity.Sub(take)
+		if !l.Quantity.IsPositive() {
+			*levels = (*levels)[1:]
+		}
+	}
+
+	if filled {
+		b.Sequence++
+	}
+
+	if !o.Remaining().IsPositive() {
+		e.Close(o)
+	}
+
+	return filled
+}
+
+func crosses(o *Order, rate decimal.Decimal) bool {
+	switch {
+	case o.Type == Market:
+		return true
+	case o.Direction == Buy:
+		return rate.LessThanOrEqual(o.Limit)
+	}
+
+	return rate.GreaterThanOrEqual(o.Limit)
+}
+
+// fill executes qty of o at rate and settles the balances
+func (e *Engine) fill(o *Order, qty, rate, fee decimal.Decimal) {
+	m := e.symbols[o.MarketSymbol]
+	proceeds := qty.Mul(rate)
+	commission := proceeds.Mul(fee)
+
+	o.FillQuantity = o.FillQuantity.Add(qty)
+	o.Proceeds = o.Proceeds.Add(proceeds)
+	o.Commission = o.Commission.Add(commission)
+	o.UpdatedAt = time.Now().UTC()
+
+	if o.Direction == Buy {
+		e.Balances[m.Base] = e.Balances[m.Base].Add(qty)
+		e.Balances[m.Quote] = e.Balances[m.Quote].Sub(proceeds).Sub(commission)
+	} else {
+		e.Balances[m.Base] = e.Balances[m.Base].Sub(qty)
+		e.Balances[m.Quote] = e.Balances[m.Quote].Add(proceeds).Sub(commission)
+	}
+}
+
+// Close closes o, cancelling what is not filled
+func (e *Engine) Close(o *Order) {
+	o.Status = Closed
+	o.ClosedAt = time.Now().UTC()
+	o.UpdatedAt = o.ClosedAt
+}
+
+// Reserved returns the balances held by open orders
+func (e *Engine) Reserved() map[string]decimal.Decimal {
+	reserved := make(map[string]decimal.Decimal)
+
+	for _, o := range e.created {
+		if o.Status != Open {
+			continue
+		}
+
+		m := e.symbols[o.MarketSymbol]
+		if o.Direction == Buy {
+			cost := o.Remaining().Mul(o.Limit)
+			reserved[m.Quote] = reserved[m.Quote].Add(cost).Add(cost.Mul(e.MakerFee))
+		} else {
+			reserved[m.Base] = reserved[m.Base].Add(o.Remaining())
+		}
+	}
+
+	return reserved
+}
+
+// SortLevels merges levels of the same rate, drops empty ones and sorts
+// them from the best one
+func SortLevels(levels []Level, bids bool) []Level {
+	byRate := make(map[string]int)
+	merged := make([]Level, 0, len(levels))
+
+	for _, l := range levels {
+		if !l.Quantity.IsPositive() {
+			continue
+		}
+
+		if i, ok := byRate[l.Rate.String()]; ok {
+			merged[i].Quantity = merged[i].Quantity.Add(l.Quantity)
+			continue
+		}
+
+		byRate[l.Rate.String()] = len(merged)
+		merged = append(merged, l)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if bids {
+			return merged[i].Rate.GreaterThan(merged[j].Rate)
+		}
+		return merged[i].Rate.LessThan(merged[j].Rate)
+	})
+
+	return merged
+}
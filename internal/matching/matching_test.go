@@ -0,0 +1,69 @@
+package matching
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func d(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestEngine(t *testing.T) {
+	e := New()
+	e.MakerFee = d("0.001")
+	e.TakerFee = d("0.002")
+	e.Balances["USD"] = d("1000")
+
+	m := Symbol{Symbol: "BTC-USD", Base: "BTC", Quote: "USD"}
+	e.SetBook("BTC-USD", nil, []Level{{Rate: d("101"), Quantity: d("1")}, {Rate: d("101"), Quantity: d("1")}, {Rate: d("100"), Quantity: d("1")}})
+
+	if b := e.Books["BTC-USD"]; len(b.Asks) != 2 || !b.Asks[0].Rate.Equal(d("100")) || !b.Asks[1].Quantity.Equal(d("2")) {
+		t.Fatalf("unexpected asks %+v", b.Asks)
+	}
+
+	o, err := e.Place(m, Request{Direction: Buy, Type: Limit, Quantity: "2", Limit: "101.5", ClientOrderID: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != Closed || !o.Proceeds.Equal(d("201")) || !o.Commission.Equal(d("0.402")) {
+		t.Errorf("unexpected taker fill %+v", o)
+	}
+
+	_, err = e.Place(m, Request{Direction: Buy, Type: Market, Quantity: "1", TimeInForce: GoodTilCancelled})
+	var rerr *Error
+	if !errors.As(err, &rerr) || rerr.Code != "INVALID_TIME_IN_FORCE" {
+		t.Errorf("market order resting on the book: %v", err)
+	}
+
+	if dup, err := e.Place(m, Request{Direction: Sell, Type: Limit, Quantity: "1", Limit: "1", ClientOrderID: "c1"}); dup != o || err == nil {
+		t.Errorf("duplicate client order ID accepted: %v", err)
+	}
+
+	if r := e.Reserved(); r["USD"].IsPositive() {
+		t.Errorf("filled order still reserves %s", r["USD"])
+	}
+
+	o, err = e.Place(m, Request{Direction: Buy, Type: Limit, Quantity: "1", Limit: "99"})
+	if err != nil || o.Status != Open {
+		t.Fatalf("unexpected order %+v %v", o, err)
+	}
+	if r := e.Reserved(); !r["USD"].Equal(d("99.099")) {
+		t.Errorf("reserved %s, want 99.099", r["USD"])
+	}
+
+	// the book moves through the resting order, which fills at its limit
+	e.SetBook("BTC-USD", nil, []Level{{Rate: d("98"), Quantity: d("5")}})
+	if !e.Match(o, true) || o.Status != Closed || !o.Proceeds.Equal(d("99")) || !o.Commission.Equal(d("0.099")) {
+		t.Errorf("unexpected maker fill %+v", o)
+	}
+
+	if closed := e.Orders("BTC-USD", false); len(closed) != 2 || closed[0] != o {
+		t.Errorf("unexpected closed orders %+v", closed)
+	}
+	if !e.Balances["BTC"].Equal(d("3")) {
+		t.Errorf("BTC balance %s, want 3", e.Balances["BTC"])
+	}
+}
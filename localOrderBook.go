@@ -0,0 +1,386 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrSequenceGap is reported when an orderbook delta does not follow the
+// sequence of the local book. The book resyncs from a new snapshot.
+var ErrSequenceGap = errors.New("orderbook sequence gap")
+
+// maxBufferedDeltas bounds the deltas buffered while the book is not synced.
+// The oldest are dropped, they are older than the next usable snapshot anyway.
+const maxBufferedDeltas = 1000
+
+// LocalOrderBook is an order book of one market kept in sync from a REST
+// snapshot and the deltas of the orderbook stream. It is safe for concurrent use.
+type LocalOrderBook struct {
+	MarketSymbol string
+	Depth        int
+	// OnError is called with snapshot errors and sequence gaps. The book
+	// resyncs on its own.
+	OnError func(err error)
+	// MinBackoff and MaxBackoff bound the exponential delay between failed
+	// resyncs. The first resync after a gap is immediate. One second and one
+	// minute are used when they are not positive.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	b        *Bittrex
+	snapshot func(book *OrderBook) error
+
+	mu       sync.RWMutex
+	synced   bool
+	sequence int
+	bids     map[string]OrderDelta
+	asks     map[string]OrderDelta
+	buffer   []OrderBook
+
+	queueMu sync.Mutex
+	queue   []OrderBook
+	queued  chan struct{}
+	changes chan struct{}
+}
+
+// NewLocalOrderBook returns a LocalOrderBook for market with depth 1, 25 or 500
+func (b *Bittrex) NewLocalOrderBook(market string, depth int) *LocalOrderBook {
+	return &LocalOrderBook{
+		MarketSymbol: strings.ToUpper(market),
+		Depth:        depth,
+		MinBackoff:   time.Second,
+		MaxBackoff:   time.Minute,
+		b:            b,
+		snapshot:     b.GetOrderBook,
+		bids:         map[string]OrderDelta{},
+		asks:         map[string]OrderDelta{},
+		queued:       make(chan struct{}, 1),
+		changes:      make(chan struct{}, 1),
+	}
+}
+
+// Channel returns the stream channel of the book
+func (l *LocalOrderBook) Channel() string {
+	return "orderbook_" + l.MarketSymbol + "_" + strconv.Itoa(l.Depth)
+}
+
+// Run subscribes the book to s and keeps it in sync until ctx is cancelled.
+// The snapshot is loaded once the first delta is received. If s is nil a
+// dedicated Stream is created and run. Run returns ctx.Err().
+func (l *LocalOrderBook) Run(ctx context.Context, s *Stream) error {
+	if s == nil {
+		s = l.b.NewStream()
+		s.Name = ORDERBOOK
+		go s.Run(ctx)
+	}
+
+	sub, err := s.Subscribe(func(msg StreamMessage) {
+		var delta OrderBook
+		if err := json.Unmarshal(msg.Data, &delta); err != nil {
+			l.error(fmt.Errorf("orderbook Unmarshal err: %s %s", err, l.MarketSymbol))
+			return
+		}
+		l.Update(delta)
+	}, l.Channel())
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	minBackoff, maxBackoff := backoffs(l.MinBackoff, l.MaxBackoff)
+	backoff := time.Duration(0)
+
+	// resyncs only happen when retry fires, deltas arriving meanwhile are
+	// buffered. It is armed by the first buffered delta, so the snapshot is
+	// not older than the deltas of the stream.
+	retry := time.NewTimer(time.Hour)
+	retry.Stop()
+	defer retry.Stop()
+	armed := false
+
+	for {
+		due := false
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.queued:
+		case <-retry.C:
+			armed = false
+			due = true
+		}
+
+		l.queueMu.Lock()
+		deltas := l.queue
+		l.queue = nil
+		l.queueMu.Unlock()
+
+		for _, d := range deltas {
+			l.apply(d)
+		}
+
+		if l.Synced() {
+			continue
+		}
+
+		if due {
+			if err := l.resync(); err != nil {
+				l.error(err)
+				backoff *= 2
+				if backoff < minBackoff {
+					backoff = minBackoff
+				}
+				if backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+			} else {
+				backoff = 0
+				continue
+			}
+		}
+
+		if !armed {
+			retry.Reset(backoff)
+			armed = true
+		}
+	}
+}
+
+// Update queues an orderbook delta received from the stream. It is used by
+// Run and may be called directly when deltas are received elsewhere.
+func (l *LocalOrderBook) Update(delta OrderBook) {
+	l.queueMu.Lock()
+	l.queue = append(l.queue, delta)
+	l.queueMu.Unlock()
+
+	select {
+	case l.queued <- struct{}{}:
+	default:
+	}
+}
+
+// Changes returns a channel which receives after the book changed.
+// Notifications are coalesced.
+func (l *LocalOrderBook) Changes() <-chan struct{} {
+	return l.changes
+}
+
+// Synced reports whether the book is in sync with the exchange
+func (l *LocalOrderBook) Synced() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.synced
+}
+
+// Sequence returns the sequence of the last applied delta
+func (l *LocalOrderBook) Sequence() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.sequence
+}
+
+// BestBid returns the highest bid
+func (l *LocalOrderBook) BestBid() (OrderDelta, bool) {
+	bids := l.Bids(1)
+	if len(bids) == 0 {
+		return OrderDelta{}, false
+	}
+
+	return bids[0], true
+}
+
+// BestAsk returns the lowest ask
+func (l *LocalOrderBook) BestAsk() (OrderDelta, bool) {
+	asks := l.Asks(1)
+	if len(asks) == 0 {
+		return OrderDelta{}, false
+	}
+
+	return asks[0], true
+}
+
+// Bids returns the n best bids, highest first. n <= 0 returns all levels.
+func (l *LocalOrderBook) Bids(n int) []OrderDelta {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return topLevels(l.bids, n, true)
+}
+
+// Asks returns the n best asks, lowest first. n <= 0 returns all levels.
+func (l *LocalOrderBook) Asks(n int) []OrderDelta {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return topLevels(l.asks, n, false)
+}
+
+// DepthAt returns the quantity resting at price on either side of the book
+func (l *LocalOrderBook) DepthAt(price decimal.Decimal) decimal.Decimal {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if d, ok := l.bids[price.String()]; ok {
+		return d.Quantity
+	}
+
+	return l.asks[price.String()].Quantity
+}
+
+// Snapshot returns a copy of the book with sorted levels
+func (l *LocalOrderBook) Snapshot() OrderBook {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return OrderBook{
+		MarketSymbol: l.MarketSymbol,
+		Depth:        l.Depth,
+		Sequence:     l.sequence,
+		BidDeltas:    topLevels(l.bids, 0, true),
+		AskDeltas:    topLevels(l.asks, 0, false),
+	}
+}
+
+// apply applies a delta, or buffers it while the book is not synced
+func (l *LocalOrderBook) apply(delta OrderBook) {
+	l.mu.Lock()
+
+	if !l.synced {
+		l.buffer = append(l.buffer, delta)
+		if len(l.buffer) > maxBufferedDeltas {
+			l.buffer = l.buffer[len(l.buffer)-maxBufferedDeltas:]
+		}
+		l.mu.Unlock()
+		return
+	}
+
+	if delta.Sequence <= l.sequence {
+		l.mu.Unlock()
+		return
+	}
+
+	if delta.Sequence != l.sequence+1 {
+		l.synced = false
+		l.buffer = []OrderBook{delta}
+		seq := l.sequence
+		l.mu.Unlock()
+
+		l.error(fmt.Errorf("%w: %s expected %d got %d", ErrSequenceGap, l.MarketSymbol, seq+1, delta.Sequence))
+		return
+	}
+
+	l.applyLocked(delta)
+	l.mu.Unlock()
+
+	l.notify()
+}
+
+// resync loads a snapshot and applies the buffered deltas which follow it
+func (l *LocalOrderBook) resync() error {
+	snap := OrderBook{MarketSymbol: l.MarketSymbol, Depth: l.Depth}
+	if err := l.snapshot(&snap); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+
+	var pending []OrderBook
+	for _, d := range l.buffer {
+		if d.Sequence > snap.Sequence {
+			pending = append(pending, d)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Sequence < pending[j].Sequence })
+
+	if len(pending) > 0 && pending[0].Sequence > snap.Sequence+1 {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: %s snapshot %d is older than delta %d", ErrSequenceGap, l.MarketSymbol, snap.Sequence, pending[0].Sequence)
+	}
+
+	l.bids = map[string]OrderDelta{}
+	l.asks = map[string]OrderDelta{}
+	l.sequence = snap.Sequence
+	l.applyLocked(OrderBook{Sequence: snap.Sequence, BidDeltas: snap.BidDeltas, AskDeltas: snap.AskDeltas})
+
+	for _, d := range pending {
+		if d.Sequence != l.sequence+1 {
+			l.buffer = nil
+			l.mu.Unlock()
+			return fmt.Errorf("%w: %s expected %d got %d", ErrSequenceGap, l.MarketSymbol, l.sequence+1, d.Sequence)
+		}
+		l.applyLocked(d)
+	}
+
+	l.buffer = nil
+	l.synced = true
+	l.mu.Unlock()
+
+	l.notify()
+	return nil
+}
+
+func (l *LocalOrderBook) applyLocked(delta OrderBook) {
+	for _, d := range delta.BidDeltas {
+		setLevel(l.bids, d)
+	}
+
+	for _, d := range delta.AskDeltas {
+		setLevel(l.asks, d)
+	}
+
+	l.sequence = delta.Sequence
+}
+
+func (l *LocalOrderBook) notify() {
+	select {
+	case l.changes <- struct{}{}:
+	default:
+	}
+}
+
+func (l *LocalOrderBook) error(err error) {
+	if l.OnError != nil {
+		l.OnError(err)
+	}
+}
+
+// setLevel updates a price level, a zero quantity removes it
+func setLevel(levels map[string]OrderDelta, d OrderDelta) {
+	if d.Quantity.IsZero() {
+		delete(levels, d.Rate.String())
+		return
+	}
+
+	levels[d.Rate.String()] = d
+}
+
+func topLevels(levels map[string]OrderDelta, n int, desc bool) []OrderDelta {
+	out := make([]OrderDelta, 0, len(levels))
+	for _, d := range levels {
+		out = append(out, d)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if desc {
+			return out[i].Rate.GreaterThan(out[j].Rate)
+		}
+		return out[i].Rate.LessThan(out[j].Rate)
+	})
+
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
@@ -0,0 +1,158 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func level(rate, qty string) OrderDelta {
+	return OrderDelta{Rate: decimal.RequireFromString(rate), Quantity: decimal.RequireFromString(qty)}
+}
+
+func TestLocalOrderBookSync(t *testing.T) {
+	l := New("", "").NewLocalOrderBook("btc-usd", 25)
+
+	snapshots := 0
+	l.snapshot = func(book *OrderBook) error {
+		snapshots++
+		book.Sequence = 10
+		book.BidDeltas = []OrderDelta{level("100", "1"), level("99", "2")}
+		book.AskDeltas = []OrderDelta{level("101", "1"), level("102", "3")}
+		return nil
+	}
+
+	var gaps int
+	l.OnError = func(err error) {
+		if errors.Is(err, ErrSequenceGap) {
+			gaps++
+		}
+	}
+
+	// buffered before the snapshot, 9 and 10 are stale
+	l.apply(OrderBook{Sequence: 10, BidDeltas: []OrderDelta{level("100", "5")}})
+	l.apply(OrderBook{Sequence: 11, BidDeltas: []OrderDelta{level("100", "0")}})
+
+	if err := l.resync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if bid, _ := l.BestBid(); !bid.Rate.Equal(decimal.NewFromInt(99)) {
+		t.Errorf("best bid %s, want 99", bid.Rate)
+	}
+
+	l.apply(OrderBook{Sequence: 12, AskDeltas: []OrderDelta{level("100.5", "4")}})
+
+	if ask, _ := l.BestAsk(); !ask.Rate.Equal(decimal.RequireFromString("100.5")) {
+		t.Errorf("best ask %s, want 100.5", ask.Rate)
+	}
+
+	if q := l.DepthAt(decimal.RequireFromString("102.0")); !q.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("depth at 102 is %s, want 3", q)
+	}
+
+	if asks := l.Asks(2); len(asks) != 2 || l.Sequence() != 12 {
+		t.Errorf("unexpected asks %v at %d", asks, l.Sequence())
+	}
+
+	l.apply(OrderBook{Sequence: 14})
+	if l.Synced() || gaps != 1 {
+		t.Fatalf("gap not detected: synced=%v gaps=%d", l.Synced(), gaps)
+	}
+
+	if err := l.resync(); !errors.Is(err, ErrSequenceGap) || snapshots != 2 {
+		t.Fatalf("stale snapshot accepted: %v", err)
+	}
+}
+
+func TestLocalOrderBookResyncThrottle(t *testing.T) {
+	b := New("", "")
+	l := b.NewLocalOrderBook("btc-usd", 25)
+	l.MinBackoff = 100 * time.Millisecond
+
+	var snapshots int32
+	l.snapshot = func(book *OrderBook) error {
+		atomic.AddInt32(&snapshots, 1)
+		book.Sequence = 1
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- l.Run(ctx, b.NewStream()) }()
+
+	// every delta is newer than the stale snapshot
+	for seq, deadline := 100, time.Now().Add(350*time.Millisecond); time.Now().Before(deadline); seq++ {
+		l.Update(OrderBook{Sequence: seq})
+		time.Sleep(100 * time.Microsecond)
+	}
+
+	cancel()
+	<-done
+
+	// attempts at 0, 100ms and 300ms
+	if n := atomic.LoadInt32(&snapshots); n < 1 || n > 3 {
+		t.Errorf("%d snapshots in 350ms", n)
+	}
+
+	for seq := 0; seq < 2*maxBufferedDeltas; seq++ {
+		l.apply(OrderBook{Sequence: seq})
+	}
+
+	if n := len(l.buffer); n != maxBufferedDeltas || l.buffer[n-1].Sequence != 2*maxBufferedDeltas-1 {
+		t.Errorf("%d deltas buffered", n)
+	}
+}
+
+func TestLocalOrderBookStart(t *testing.T) {
+	b := New("", "")
+	l := b.NewLocalOrderBook("btc-usd", 25)
+
+	var snapshots int32
+	l.snapshot = func(book *OrderBook) error {
+		atomic.AddInt32(&snapshots, 1)
+		book.Sequence = 10
+		return nil
+	}
+
+	errs := make(chan error, 1)
+	l.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- l.Run(ctx, b.NewStream()) }()
+
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&snapshots); n != 0 {
+		t.Fatalf("%d snapshots before the first delta", n)
+	}
+
+	l.Update(OrderBook{Sequence: 11})
+	for deadline := time.Now().Add(time.Second); !l.Synced(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("book not synced")
+		}
+	}
+
+	cancel()
+	<-done
+
+	select {
+	case err := <-errs:
+		t.Errorf("unexpected error %v", err)
+	default:
+	}
+}
@@ -4,16 +4,17 @@ import "github.com/shopspring/decimal"
 
 // MarketSummary struct
 type MarketSummary struct {
-	MarketName     string          `json:"MarketName"`
-	High           decimal.Decimal `json:"High"`
-	Low            decimal.Decimal `json:"Low"`
-	Ask            decimal.Decimal `json:"Ask"`
-	Bid            decimal.Decimal `json:"Bid"`
-	OpenBuyOrders  int             `json:"OpenBuyOrders"`
-	OpenSellOrders int             `json:"OpenSellOrders"`
-	Volume         decimal.Decimal `json:"Volume"`
-	Last           decimal.Decimal `json:"Last"`
-	BaseVolume     decimal.Decimal `json:"BaseVolume"`
-	PrevDay        decimal.Decimal `json:"PrevDay"`
-	TimeStamp      string          `json:"TimeStamp"`
+	Symbol        string          `json:"symbol"`
+	High          decimal.Decimal `json:"high"`
+	Low           decimal.Decimal `json:"low"`
+	Volume        decimal.Decimal `json:"volume"`
+	QuoteVolume   decimal.Decimal `json:"quoteVolume"`
+	PercentChange decimal.Decimal `json:"percentChange"`
+	UpdatedAt     jTime           `json:"updatedAt"`
+}
+
+// MarketSummariesUpdate is a market_summaries stream message
+type MarketSummariesUpdate struct {
+	Sequence int             `json:"sequence"`
+	Deltas   []MarketSummary `json:"deltas"`
 }
@@ -0,0 +1,143 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MarketSummaryCache keeps the summaries of all markets up to date from the
+// market_summaries stream. It is safe for concurrent use.
+type MarketSummaryCache struct {
+	// OnError is called with snapshot errors and sequence gaps. The cache
+	// reloads the summaries on its own.
+	OnError func(err error)
+
+	b *Bittrex
+
+	mu        sync.RWMutex
+	sequence  int
+	summaries map[string]MarketSummary
+
+	resync chan struct{}
+}
+
+// NewMarketSummaryCache returns an empty MarketSummaryCache
+func (b *Bittrex) NewMarketSummaryCache() *MarketSummaryCache {
+	return &MarketSummaryCache{
+		b:         b,
+		summaries: map[string]MarketSummary{},
+		resync:    make(chan struct{}, 1),
+	}
+}
+
+// Run subscribes the cache to s, loads all summaries with GetMarketSummaries
+// and keeps them up to date until ctx is cancelled. If s is nil a dedicated
+// Stream is created and run. Run returns ctx.Err().
+func (c *MarketSummaryCache) Run(ctx context.Context, s *Stream) error {
+	if s == nil {
+		s = c.b.NewStream()
+		s.Name = MARKETSUMMARIES
+		go s.Run(ctx)
+	}
+
+	sub, err := s.Subscribe(func(msg StreamMessage) {
+		var u MarketSummariesUpdate
+		if err := json.Unmarshal(msg.Data, &u); err != nil {
+			c.error(fmt.Errorf("market summaries Unmarshal err: %s", err))
+			return
+		}
+		c.Update(u)
+	}, "market_summaries")
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.resync:
+		case <-retry.C:
+		}
+
+		summaries, err := c.b.GetMarketSummaries()
+		if err != nil {
+			c.error(err)
+			retry.Reset(time.Second)
+			continue
+		}
+
+		c.mu.Lock()
+		for _, m := range summaries {
+			key := strings.ToUpper(m.Symbol)
+			if cur, ok := c.summaries[key]; ok && cur.UpdatedAt.After(m.UpdatedAt.Time) {
+				continue
+			}
+			c.summaries[key] = m
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Update applies a market_summaries stream message. It is used by Run and
+// may be called directly when updates are received elsewhere.
+func (c *MarketSummaryCache) Update(u MarketSummariesUpdate) {
+	c.mu.Lock()
+
+	if u.Sequence <= c.sequence {
+		c.mu.Unlock()
+		return
+	}
+
+	gap := c.sequence != 0 && u.Sequence != c.sequence+1
+	c.sequence = u.Sequence
+
+	for _, m := range u.Deltas {
+		c.summaries[strings.ToUpper(m.Symbol)] = m
+	}
+	c.mu.Unlock()
+
+	if gap {
+		c.error(fmt.Errorf("%w: market summaries got %d", ErrSequenceGap, u.Sequence))
+		select {
+		case c.resync <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Get returns the summary of market
+func (c *MarketSummaryCache) Get(market string) (MarketSummary, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	m, ok := c.summaries[strings.ToUpper(market)]
+	return m, ok
+}
+
+// All returns a copy of all summaries keyed by market symbol
+func (c *MarketSummaryCache) All() map[string]MarketSummary {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make(map[string]MarketSummary, len(c.summaries))
+	for k, v := range c.summaries {
+		out[k] = v
+	}
+
+	return out
+}
+
+func (c *MarketSummaryCache) error(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+	}
+}
@@ -0,0 +1,51 @@
+package bittrex
+
+import "time"
+
+// Metrics receives counters and timings for REST and WebSocket activity.
+// The stream label is the Name of the Stream and method is a hub method, so
+// both have a bounded set of values. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	// ObserveRequest records a completed REST call. status is zero and code is
+	// empty when no response was received.
+	ObserveRequest(endpoint, method string, status int, code string, duration time.Duration)
+	// StreamMessage records a message of the given hub method received on stream
+	StreamMessage(stream, method string)
+	// StreamDropped records a message of the given hub method dropped because
+	// the consumer was too slow
+	StreamDropped(stream, method string)
+	// StreamConnect records a successful hub connection for stream
+	StreamConnect(stream string)
+	// StreamDisconnect records a lost connection or heartbeat timeout for stream
+	StreamDisconnect(stream string)
+	// StreamReconnect records a successful reconnect of a supervised stream
+	StreamReconnect(stream string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(string, string, int, string, time.Duration) {}
+func (nopMetrics) StreamMessage(string, string)                              {}
+func (nopMetrics) StreamDropped(string, string)                              {}
+func (nopMetrics) StreamConnect(string)                                      {}
+func (nopMetrics) StreamDisconnect(string)                                   {}
+func (nopMetrics) StreamReconnect(string)                                    {}
+
+// SetMetrics sets the metrics sink for REST and WebSocket activity.
+// It should be called before the client is used.
+func (b *Bittrex) SetMetrics(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
+	}
+	b.metrics = m
+}
+
+func (b *Bittrex) observeResponse(info ResponseInfo) {
+	var code string
+	if info.APIError != nil {
+		code = info.APIError.Code
+	}
+
+	b.metrics.ObserveRequest(info.Endpoint, info.Method, info.StatusCode, code, info.Duration)
+}
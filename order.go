@@ -26,26 +26,71 @@ type Order struct {
 		Type string `json:"type"`
 		ID   string `json:"id"`
 	} `json:"orderToCancel"`
+	// Sequence is the order stream sequence last merged into the order
+	Sequence int `json:"-"`
 }
 
 //OrderUpdate struct
 type OrderUpdate struct {
 	AccountID string `json:"accountId"`
 	Sequence  int    `json:"sequence"`
-	Delta     struct {
-		ID           string `json:"id"`
-		MarketSymbol string `json:"marketSymbol"`
-		Direction    string `json:"direction"`
-		Type         string `json:"type"`
-		Quantity     string `json:"quantity"`
-		Limit        string `json:"limit"`
-		TimeInForce  string `json:"timeInForce"`
-		FillQuantity string `json:"fillQuantity"`
-		Commission   string `json:"commission"`
-		Proceeds     string `json:"proceeds"`
-		Status       string `json:"status"`
-		CreatedAt    jTime  `json:"createdAt"`
-		UpdatedAt    *jTime `json:"updatedAt"`
-		ClosedAt     *jTime `json:"closedAt"`
-	} `json:"delta"`
+	Delta     Order  `json:"delta"`
+}
+
+// MergeOrderUpdate applies the fields set in upd.Delta to existing when
+// upd is newer than the last update merged into it. It reports whether the
+// update was applied.
+func MergeOrderUpdate(existing *Order, upd OrderUpdate) bool {
+	d := upd.Delta
+
+	if existing.ID != "" && d.ID != "" && existing.ID != d.ID {
+		return false
+	}
+
+	if upd.Sequence <= existing.Sequence {
+		return false
+	}
+
+	mergeString(&existing.ID, d.ID)
+	mergeString(&existing.MarketSymbol, d.MarketSymbol)
+	mergeString(&existing.Direction, d.Direction)
+	mergeString(&existing.Type, d.Type)
+	mergeString(&existing.TimeInForce, d.TimeInForce)
+	mergeString(&existing.ClientOrderID, d.ClientOrderID)
+	mergeString(&existing.Status, d.Status)
+
+	mergeDecimal(&existing.Quantity, d.Quantity)
+	mergeDecimal(&existing.Limit, d.Limit)
+	mergeDecimal(&existing.Ceiling, d.Ceiling)
+	mergeDecimal(&existing.FillQuantity, d.FillQuantity)
+	mergeDecimal(&existing.Commission, d.Commission)
+	mergeDecimal(&existing.Proceeds, d.Proceeds)
+
+	if !d.CreatedAt.IsZero() {
+		existing.CreatedAt = d.CreatedAt
+	}
+	if d.UpdatedAt != nil {
+		existing.UpdatedAt = d.UpdatedAt
+	}
+	if d.ClosedAt != nil {
+		existing.ClosedAt = d.ClosedAt
+	}
+	if d.OrderToCancel.ID != "" {
+		existing.OrderToCancel = d.OrderToCancel
+	}
+
+	existing.Sequence = upd.Sequence
+	return true
+}
+
+func mergeString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func mergeDecimal(dst *decimal.Decimal, v decimal.Decimal) {
+	if !v.IsZero() {
+		*dst = v
+	}
 }
@@ -0,0 +1,223 @@
+package bittrex
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInsufficientDepth is returned when the book cannot fill the requested quantity
+var ErrInsufficientDepth = errors.New("insufficient orderbook depth")
+
+var (
+	decimalTwo  = decimal.NewFromInt(2)
+	decimalTenK = decimal.NewFromInt(10000)
+)
+
+// Fill is the result of walking the book for a given quantity
+type Fill struct {
+	// Quantity is the filled quantity, less than requested on ErrInsufficientDepth
+	Quantity decimal.Decimal
+	// Cost is the total quote amount, sum of quantity * rate of every level
+	Cost decimal.Decimal
+	// AveragePrice is the volume weighted average fill price
+	AveragePrice decimal.Decimal
+	// BestPrice is the price of the first level touched
+	BestPrice decimal.Decimal
+	// WorstPrice is the price of the last level touched
+	WorstPrice decimal.Decimal
+	// SlippageBps is the distance of AveragePrice from BestPrice in basis points
+	SlippageBps decimal.Decimal
+}
+
+// BestBid returns the highest bid
+func (o OrderBook) BestBid() (OrderDelta, bool) {
+	bids := o.sortedBids()
+	if len(bids) == 0 {
+		return OrderDelta{}, false
+	}
+
+	return bids[0], true
+}
+
+// BestAsk returns the lowest ask
+func (o OrderBook) BestAsk() (OrderDelta, bool) {
+	asks := o.sortedAsks()
+	if len(asks) == 0 {
+		return OrderDelta{}, false
+	}
+
+	return asks[0], true
+}
+
+// MidPrice returns the average of the best bid and ask
+func (o OrderBook) MidPrice() (decimal.Decimal, bool) {
+	bid, ask, ok := o.top()
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	return bid.Rate.Add(ask.Rate).Div(decimalTwo), true
+}
+
+// Spread returns the best ask minus the best bid
+func (o OrderBook) Spread() (decimal.Decimal, bool) {
+	bid, ask, ok := o.top()
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	return ask.Rate.Sub(bid.Rate), true
+}
+
+// BuyCost walks the asks to buy quantity
+func (o OrderBook) BuyCost(quantity decimal.Decimal) (Fill, error) {
+	return walk(o.sortedAsks(), quantity, false)
+}
+
+// SellCost walks the bids to sell quantity
+func (o OrderBook) SellCost(quantity decimal.Decimal) (Fill, error) {
+	return walk(o.sortedBids(), quantity, true)
+}
+
+// QuantityWithin returns the bid and ask quantity resting within bps basis
+// points of the mid price.
+func (o OrderBook) QuantityWithin(bps int64) (bid, ask decimal.Decimal) {
+	mid, ok := o.MidPrice()
+	if !ok {
+		return decimal.Zero, decimal.Zero
+	}
+
+	offset := mid.Mul(decimal.NewFromInt(bps)).Div(decimalTenK)
+	low, high := mid.Sub(offset), mid.Add(offset)
+
+	for _, d := range o.BidDeltas {
+		if d.Rate.GreaterThanOrEqual(low) {
+			bid = bid.Add(d.Quantity)
+		}
+	}
+
+	for _, d := range o.AskDeltas {
+		if d.Rate.LessThanOrEqual(high) {
+			ask = ask.Add(d.Quantity)
+		}
+	}
+
+	return bid, ask
+}
+
+// Imbalance returns (bidVolume - askVolume) / (bidVolume + askVolume) over
+// the best levels of each side, in [-1, 1]. levels <= 0 uses the whole book.
+// The result is rounded to decimal.DivisionPrecision digits.
+func (o OrderBook) Imbalance(levels int) (decimal.Decimal, bool) {
+	bidVolume := volume(o.sortedBids(), levels)
+	askVolume := volume(o.sortedAsks(), levels)
+
+	total := bidVolume.Add(askVolume)
+	if total.IsZero() {
+		return decimal.Zero, false
+	}
+
+	return bidVolume.Sub(askVolume).Div(total), true
+}
+
+// MicroPrice returns the best bid and ask weighted by the opposite side
+// quantity, rounded to decimal.DivisionPrecision digits.
+func (o OrderBook) MicroPrice() (decimal.Decimal, bool) {
+	bid, ask, ok := o.top()
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	total := bid.Quantity.Add(ask.Quantity)
+	if total.IsZero() {
+		return decimal.Zero, false
+	}
+
+	return bid.Rate.Mul(ask.Quantity).Add(ask.Rate.Mul(bid.Quantity)).Div(total), true
+}
+
+func (o OrderBook) top() (bid, ask OrderDelta, ok bool) {
+	bid, okBid := o.BestBid()
+	ask, okAsk := o.BestAsk()
+
+	return bid, ask, okBid && okAsk
+}
+
+func (o OrderBook) sortedBids() []OrderDelta {
+	return sortLevels(o.BidDeltas, true)
+}
+
+func (o OrderBook) sortedAsks() []OrderDelta {
+	return sortLevels(o.AskDeltas, false)
+}
+
+// sortLevels returns the non empty levels sorted from the best price
+func sortLevels(levels []OrderDelta, desc bool) []OrderDelta {
+	out := make([]OrderDelta, 0, len(levels))
+	for _, d := range levels {
+		if d.Quantity.IsPositive() {
+			out = append(out, d)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if desc {
+			return out[i].Rate.GreaterThan(out[j].Rate)
+		}
+		return out[i].Rate.LessThan(out[j].Rate)
+	})
+
+	return out
+}
+
+func volume(levels []OrderDelta, n int) decimal.Decimal {
+	var v decimal.Decimal
+	for i, d := range levels {
+		if n > 0 && i >= n {
+			break
+		}
+		v = v.Add(d.Quantity)
+	}
+
+	return v
+}
+
+// walk fills quantity from sorted levels
+func walk(levels []OrderDelta, quantity decimal.Decimal, sell bool) (Fill, error) {
+	var f Fill
+	remaining := quantity
+
+	for _, d := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+
+		q := decimal.Min(remaining, d.Quantity)
+		if f.Quantity.IsZero() {
+			f.BestPrice = d.Rate
+		}
+
+		f.Quantity = f.Quantity.Add(q)
+		f.Cost = f.Cost.Add(q.Mul(d.Rate))
+		f.WorstPrice = d.Rate
+		remaining = remaining.Sub(q)
+	}
+
+	if f.Quantity.IsPositive() {
+		f.AveragePrice = f.Cost.Div(f.Quantity)
+
+		slippage := f.AveragePrice.Sub(f.BestPrice)
+		if sell {
+			slippage = slippage.Neg()
+		}
+		f.SlippageBps = slippage.Mul(decimalTenK).Div(f.BestPrice)
+	}
+
+	if remaining.IsPositive() {
+		return f, ErrInsufficientDepth
+	}
+
+	return f, nil
+}
@@ -0,0 +1,46 @@
+package bittrex
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderBookAnalytics(t *testing.T) {
+	book := OrderBook{
+		BidDeltas: []OrderDelta{level("99", "2"), level("100", "1")},
+		AskDeltas: []OrderDelta{level("102", "3"), level("101", "1"), level("103", "0")},
+	}
+
+	d := decimal.RequireFromString
+
+	mid, _ := book.MidPrice()
+	spread, _ := book.Spread()
+	if !mid.Equal(d("100.5")) || !spread.Equal(d("1")) {
+		t.Errorf("mid %s spread %s", mid, spread)
+	}
+
+	fill, err := book.BuyCost(d("2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fill.AveragePrice.Equal(d("101.5")) || !fill.WorstPrice.Equal(d("102")) || !fill.Cost.Equal(d("203")) {
+		t.Errorf("unexpected buy fill %+v", fill)
+	}
+
+	fill, err = book.SellCost(d("4"))
+	if err != ErrInsufficientDepth || !fill.Quantity.Equal(d("3")) || !fill.AveragePrice.Equal(d("298").Div(d("3"))) {
+		t.Errorf("unexpected sell fill %+v %v", fill, err)
+	}
+
+	bid, ask := book.QuantityWithin(200)
+	if !bid.Equal(d("3")) || !ask.Equal(d("4")) {
+		t.Errorf("quantity within 200bps: bid %s ask %s", bid, ask)
+	}
+
+	imbalance, _ := book.Imbalance(1)
+	micro, _ := book.MicroPrice()
+	if !imbalance.IsZero() || !micro.Equal(mid) {
+		t.Errorf("imbalance %s microprice %s", imbalance, micro)
+	}
+}
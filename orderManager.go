@@ -0,0 +1,457 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Order statuses
+const (
+	ORDEROPEN   = "OPEN"
+	ORDERCLOSED = "CLOSED"
+)
+
+// OrderEventType is the kind of an OrderEvent
+type OrderEventType int
+
+const (
+	// OrderAccepted is emitted when an open order is seen for the first time
+	OrderAccepted OrderEventType = iota
+	// OrderPartiallyFilled is emitted when the fill quantity of an open order grows
+	OrderPartiallyFilled
+	// OrderFilled is emitted when an order closes completely filled
+	OrderFilled
+	// OrderCancelled is emitted when an order closes not or partially filled
+	OrderCancelled
+	// OrderRejected is emitted when Place fails
+	OrderRejected
+)
+
+func (t OrderEventType) String() string {
+	switch t {
+	case OrderAccepted:
+		return "accepted"
+	case OrderPartiallyFilled:
+		return "partially filled"
+	case OrderFilled:
+		return "filled"
+	case OrderCancelled:
+		return "cancelled"
+	case OrderRejected:
+		return "rejected"
+	}
+
+	return "unknown"
+}
+
+// OrderEvent is a lifecycle event of an order tracked by an OrderManager
+type OrderEvent struct {
+	Type  OrderEventType
+	Order Order
+	// Err is the NewOrder error of an OrderRejected event
+	Err error
+}
+
+// OrderManager tracks the state of the account orders from the REST API and
+// the order stream. It is safe for concurrent use.
+type OrderManager struct {
+	// OnEvent is called with the lifecycle events of the orders. It is called
+	// from the order stream and must not block.
+	OnEvent func(ev OrderEvent)
+	// OnError is called with reconcile errors, sequence gaps and dropped
+	// updates. The manager reconciles again on its own.
+	OnError func(err error)
+	// MaxClosed bounds the closed orders kept, the ones which closed first
+	// are forgotten first. 1000 is used when it is not positive.
+	MaxClosed int
+
+	b *Bittrex
+
+	mu         sync.RWMutex
+	sequence   int
+	orders     map[string]*Order
+	byClientID map[string]string
+	// closed holds the IDs of the closed orders in the order they closed
+	closed []string
+
+	resync chan struct{}
+}
+
+// NewOrderManager returns an empty OrderManager
+func (b *Bittrex) NewOrderManager() *OrderManager {
+	return &OrderManager{
+		MaxClosed:  1000,
+		b:          b,
+		orders:     map[string]*Order{},
+		byClientID: map[string]string{},
+		resync:     make(chan struct{}, 1),
+	}
+}
+
+// Run subscribes the manager to the order channel of s, seeds it from the
+// open orders and keeps it up to date until ctx is cancelled. If s is nil a
+// dedicated authenticated Stream is created and run, and the manager
+// reconciles after every reconnect. A Stream passed in must be authenticated
+// and should call Resync from its OnGap. Run returns ctx.Err().
+func (m *OrderManager) Run(ctx context.Context, s *Stream) error {
+	if s == nil {
+		s = m.b.NewStream()
+		s.Name = ORDER
+		s.OnGap = m.Resync
+		s.Authenticate()
+		go s.Run(ctx)
+	}
+
+	sub, err := s.Subscribe(func(msg StreamMessage) {
+		var upd OrderUpdate
+		if err := json.Unmarshal(msg.Data, &upd); err != nil {
+			m.error(fmt.Errorf("order Unmarshal err: %s", err))
+			return
+		}
+		m.Update(upd)
+	}, "order")
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.resync:
+		case <-retry.C:
+		}
+
+		if err := m.reconcile(); err != nil {
+			m.error(err)
+			retry.Reset(time.Second)
+		}
+	}
+}
+
+// Resync makes Run reconcile the tracked orders with the REST API
+func (m *OrderManager) Resync() {
+	select {
+	case m.resync <- struct{}{}:
+	default:
+	}
+}
+
+// Place places order and tracks it. A failed placement emits OrderRejected.
+func (m *OrderManager) Place(order NewOrder) (Order, error) {
+	r, err := m.b.NewOrder(order)
+	if err != nil {
+		m.emit([]OrderEvent{{Type: OrderRejected, Order: Order{
+			MarketSymbol:  order.MarketSymbol,
+			Direction:     order.Direction,
+			Type:          order.Type,
+			TimeInForce:   order.TimeInForce,
+			ClientOrderID: order.ClientOrderID,
+		}, Err: err}})
+		return Order{}, err
+	}
+
+	var o Order
+	if err := json.Unmarshal(r, &o); err != nil {
+		return Order{}, err
+	}
+
+	m.mu.Lock()
+	events := m.storeLocked(o)
+	m.mu.Unlock()
+
+	m.emit(events)
+	return o, nil
+}
+
+// Update applies an order stream message. It is used by Run and may be
+// called directly when updates are received elsewhere.
+func (m *OrderManager) Update(upd OrderUpdate) {
+	m.mu.Lock()
+
+	var gap error
+	if m.sequence != 0 && upd.Sequence > m.sequence+1 {
+		gap = fmt.Errorf("%w: orders expected %d got %d", ErrSequenceGap, m.sequence+1, upd.Sequence)
+	}
+	if upd.Sequence > m.sequence {
+		m.sequence = upd.Sequence
+	}
+
+	var events []OrderEvent
+
+	if upd.Delta.ID == "" {
+		m.mu.Unlock()
+
+		m.error(fmt.Errorf("order update %d without order id dropped", upd.Sequence))
+		if gap != nil {
+			m.error(gap)
+			m.Resync()
+		}
+		return
+	}
+
+	if cur, ok := m.orders[upd.Delta.ID]; ok {
+		prev := *cur
+		if MergeOrderUpdate(cur, upd) {
+			m.indexLocked(cur)
+			m.closeLocked(&prev, cur)
+			events = orderEvents(&prev, *cur)
+		}
+	} else {
+		o := upd.Delta
+		o.Sequence = upd.Sequence
+		events = m.storeLocked(o)
+	}
+
+	m.mu.Unlock()
+
+	if gap != nil {
+		m.error(gap)
+		m.Resync()
+	}
+
+	m.emit(events)
+}
+
+// Get returns the order with id
+func (m *OrderManager) Get(id string) (Order, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.orders[id]
+	if !ok {
+		return Order{}, false
+	}
+
+	return *o, true
+}
+
+// GetByClientOrderID returns the order with the client order id
+func (m *OrderManager) GetByClientOrderID(clientOrderID string) (Order, bool) {
+	m.mu.RLock()
+	id, ok := m.byClientID[clientOrderID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return Order{}, false
+	}
+
+	return m.Get(id)
+}
+
+// Open returns the open orders, oldest first
+func (m *OrderManager) Open() []Order {
+	return m.list(func(o *Order) bool { return o.Status != ORDERCLOSED })
+}
+
+// Closed returns the orders which closed while tracked, oldest first. Only
+// the last MaxClosed of them are kept.
+func (m *OrderManager) Closed() []Order {
+	return m.list(func(o *Order) bool { return o.Status == ORDERCLOSED })
+}
+
+// Forget stops tracking the closed order with id. Open orders are kept.
+// A later update of a forgotten order tracks it again.
+func (m *OrderManager) Forget(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[id]
+	if !ok || o.Status != ORDERCLOSED {
+		return
+	}
+
+	for i, c := range m.closed {
+		if c == id {
+			m.closed = append(m.closed[:i], m.closed[i+1:]...)
+			break
+		}
+	}
+
+	m.forgetLocked(o)
+}
+
+func (m *OrderManager) list(match func(o *Order) bool) []Order {
+	m.mu.RLock()
+	var out []Order
+	for _, o := range m.orders {
+		if match(o) {
+			out = append(out, *o)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt.Time) })
+
+	return out
+}
+
+// reconcile loads the open orders and the final state of tracked orders
+// which are no longer open
+func (m *OrderManager) reconcile() error {
+	open, err := m.b.GetOpenOrders("")
+	if err != nil {
+		return err
+	}
+
+	isOpen := map[string]bool{}
+	for _, o := range open {
+		isOpen[o.ID] = true
+	}
+
+	m.mu.RLock()
+	var gone []string
+	for id, o := range m.orders {
+		if o.Status != ORDERCLOSED && !isOpen[id] {
+			gone = append(gone, id)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, id := range gone {
+		o, err := m.b.GetOrder(id)
+		if err != nil {
+			return err
+		}
+		open = append(open, o)
+	}
+
+	var events []OrderEvent
+
+	m.mu.Lock()
+	for _, o := range open {
+		events = append(events, m.storeLocked(o)...)
+	}
+	m.mu.Unlock()
+
+	m.emit(events)
+	return nil
+}
+
+// storeLocked stores o unless the tracked state is more recent and returns
+// the resulting events
+func (m *OrderManager) storeLocked(o Order) []OrderEvent {
+	cur, ok := m.orders[o.ID]
+	if ok && !supersedes(o, *cur) {
+		return nil
+	}
+
+	var prev *Order
+	if ok {
+		p := *cur
+		prev = &p
+		if o.Sequence < cur.Sequence {
+			o.Sequence = cur.Sequence
+		}
+	}
+
+	m.orders[o.ID] = &o
+	m.indexLocked(&o)
+	m.closeLocked(prev, &o)
+
+	return orderEvents(prev, o)
+}
+
+// closeLocked records the transition of an order from prev, nil for a new
+// order, to a closed cur and forgets the oldest closed orders beyond MaxClosed
+func (m *OrderManager) closeLocked(prev, cur *Order) {
+	if cur.Status != ORDERCLOSED || (prev != nil && prev.Status == ORDERCLOSED) {
+		return
+	}
+
+	m.closed = append(m.closed, cur.ID)
+
+	max := m.MaxClosed
+	if max <= 0 {
+		max = 1000
+	}
+
+	for len(m.closed) > max {
+		if o, ok := m.orders[m.closed[0]]; ok {
+			m.forgetLocked(o)
+		}
+		m.closed = m.closed[1:]
+	}
+}
+
+func (m *OrderManager) forgetLocked(o *Order) {
+	delete(m.orders, o.ID)
+	if m.byClientID[o.ClientOrderID] == o.ID {
+		delete(m.byClientID, o.ClientOrderID)
+	}
+}
+
+func (m *OrderManager) indexLocked(o *Order) {
+	if o.ClientOrderID != "" {
+		m.byClientID[o.ClientOrderID] = o.ID
+	}
+}
+
+func (m *OrderManager) emit(events []OrderEvent) {
+	if m.OnEvent == nil {
+		return
+	}
+
+	for _, ev := range events {
+		m.OnEvent(ev)
+	}
+}
+
+func (m *OrderManager) error(err error) {
+	if m.OnError != nil {
+		m.OnError(err)
+	}
+}
+
+// supersedes reports whether o, read from the REST API, is at least as
+// recent as cur
+func supersedes(o, cur Order) bool {
+	if cur.Status == ORDERCLOSED {
+		return false
+	}
+
+	return o.Status == ORDERCLOSED || o.FillQuantity.GreaterThanOrEqual(cur.FillQuantity)
+}
+
+// orderEvents returns the events of the transition from prev, nil for a new
+// order, to cur
+func orderEvents(prev *Order, cur Order) []OrderEvent {
+	var events []OrderEvent
+
+	if prev == nil {
+		events = append(events, OrderEvent{Type: OrderAccepted, Order: cur})
+	}
+
+	if cur.Status == ORDERCLOSED {
+		if prev != nil && prev.Status == ORDERCLOSED {
+			return events
+		}
+
+		if cur.FillQuantity.IsPositive() && (cur.Quantity.IsZero() || cur.FillQuantity.GreaterThanOrEqual(cur.Quantity)) {
+			return append(events, OrderEvent{Type: OrderFilled, Order: cur})
+		}
+
+		return append(events, OrderEvent{Type: OrderCancelled, Order: cur})
+	}
+
+	prevFill := decimal.Zero
+	if prev != nil {
+		prevFill = prev.FillQuantity
+	}
+
+	if cur.FillQuantity.GreaterThan(prevFill) {
+		events = append(events, OrderEvent{Type: OrderPartiallyFilled, Order: cur})
+	}
+
+	return events
+}
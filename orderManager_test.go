@@ -0,0 +1,85 @@
+package bittrex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderManagerEvents(t *testing.T) {
+	m := New("", "").NewOrderManager()
+
+	var events []OrderEventType
+	m.OnEvent = func(ev OrderEvent) { events = append(events, ev.Type) }
+
+	var gaps int
+	m.OnError = func(err error) {
+		if errors.Is(err, ErrSequenceGap) {
+			gaps++
+		}
+	}
+
+	d := decimal.RequireFromString
+	order := Order{ID: "o1", ClientOrderID: "c1", Quantity: d("2"), Status: ORDEROPEN}
+
+	m.Update(OrderUpdate{Sequence: 1, Delta: order})
+
+	order.FillQuantity = d("1")
+	m.Update(OrderUpdate{Sequence: 2, Delta: order})
+
+	order.FillQuantity = d("2")
+	order.Status = ORDERCLOSED
+	m.Update(OrderUpdate{Sequence: 4, Delta: order})
+
+	want := []OrderEventType{OrderAccepted, OrderPartiallyFilled, OrderFilled}
+	if len(events) != len(want) {
+		t.Fatalf("got events %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("got events %v, want %v", events, want)
+		}
+	}
+
+	if gaps != 1 {
+		t.Errorf("got %d gaps, want 1", gaps)
+	}
+
+	o, ok := m.GetByClientOrderID("c1")
+	if !ok || o.Status != ORDERCLOSED || len(m.Open()) != 0 || len(m.Closed()) != 1 {
+		t.Errorf("unexpected state %+v", o)
+	}
+}
+
+func TestOrderManagerRetention(t *testing.T) {
+	m := New("", "").NewOrderManager()
+	m.MaxClosed = 2
+
+	var dropped int
+	m.OnError = func(err error) {
+		if !errors.Is(err, ErrSequenceGap) {
+			dropped++
+		}
+	}
+
+	m.Update(OrderUpdate{Sequence: 1, Delta: Order{Status: ORDEROPEN}})
+	if _, ok := m.Get(""); ok || dropped != 1 {
+		t.Errorf("update without order id stored, %d dropped", dropped)
+	}
+
+	for i, id := range []string{"o1", "o2", "o3"} {
+		m.Update(OrderUpdate{Sequence: i + 2, Delta: Order{ID: id, ClientOrderID: "c" + id, Status: ORDERCLOSED}})
+	}
+	m.Update(OrderUpdate{Sequence: 5, Delta: Order{ID: "o4", Status: ORDEROPEN}})
+
+	if _, ok := m.GetByClientOrderID("co1"); ok || len(m.Closed()) != 2 {
+		t.Errorf("oldest closed order kept: %+v", m.Closed())
+	}
+
+	m.Forget("o2")
+	m.Forget("o4")
+	if _, ok := m.Get("o2"); ok || len(m.Closed()) != 1 || len(m.Open()) != 1 {
+		t.Errorf("unexpected state %+v %+v", m.Closed(), m.Open())
+	}
+}
@@ -0,0 +1,39 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMergeOrderUpdate(t *testing.T) {
+	var upd OrderUpdate
+	err := json.Unmarshal([]byte(`{"accountId":"a","sequence":5,"delta":{"id":"o1","marketSymbol":"BTC-USD",
+		"quantity":"2","limit":"100","fillQuantity":"0.5","status":"OPEN","clientOrderId":"c1",
+		"createdAt":"2021-01-02T03:04:05.06Z"}}`), &upd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := Order{ID: "o1", Quantity: decimal.NewFromInt(2), Commission: decimal.RequireFromString("0.01")}
+	if !MergeOrderUpdate(&o, upd) {
+		t.Fatal("update not applied")
+	}
+
+	if !o.FillQuantity.Equal(decimal.RequireFromString("0.5")) || !o.Commission.Equal(decimal.RequireFromString("0.01")) ||
+		o.ClientOrderID != "c1" || o.Sequence != 5 || o.CreatedAt.IsZero() {
+		t.Errorf("unexpected merge result %+v", o)
+	}
+
+	upd.Delta.Status = "CLOSED"
+	if MergeOrderUpdate(&o, upd) || o.Status != "OPEN" {
+		t.Error("stale update applied")
+	}
+
+	upd.Sequence = 6
+	upd.Delta.ID = "o2"
+	if MergeOrderUpdate(&o, upd) {
+		t.Error("update of another order applied")
+	}
+}
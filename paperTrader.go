@@ -0,0 +1,384 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alexeykaravan/go-bittrex/internal/matching"
+	"github.com/shopspring/decimal"
+)
+
+// PaperTrader simulates the trading surface of Bittrex with virtual
+// balances. Orders are matched against order book snapshots fetched with
+// GetOrderBook or fed with Update and Follow; the liquidity an order takes
+// is removed from the snapshot until the next one arrives. Taker fills pay
+// TakerFee and resting orders crossed by a later snapshot fill at their
+// limit paying MakerFee, both as a rate of the proceeds and zero unless set.
+//
+// Rejected orders return an *APIError with the code Bittrex would send.
+type PaperTrader struct {
+	MakerFee decimal.Decimal
+	TakerFee decimal.Decimal
+	// OnOrderUpdate is called with a simulated order stream message for every
+	// order change. It may be OrderManager.Update.
+	OnOrderUpdate func(OrderUpdate)
+
+	b        *Bittrex
+	mu       sync.Mutex
+	engine   *matching.Engine
+	sequence int
+	changed  chan struct{}
+}
+
+// NewPaperTrader returns a PaperTrader starting with balances, keyed by currency
+func (b *Bittrex) NewPaperTrader(balances map[string]decimal.Decimal) *PaperTrader {
+	p := &PaperTrader{
+		b:       b,
+		engine:  matching.New(),
+		changed: make(chan struct{}),
+	}
+
+	for c, v := range balances {
+		p.engine.Balances[strings.ToUpper(c)] = v
+	}
+
+	return p
+}
+
+var _ Trading = (*PaperTrader)(nil)
+var _ Account = (*PaperTrader)(nil)
+
+// Update replaces the order book snapshot of a market and fills the resting
+// orders it crosses
+func (p *PaperTrader) Update(book OrderBook) {
+	market := strings.ToUpper(book.MarketSymbol)
+
+	p.mu.Lock()
+	p.setFees()
+	p.engine.SetBook(market, paperLevels(book.BidDeltas), paperLevels(book.AskDeltas))
+
+	var updates []OrderUpdate
+	for _, o := range p.engine.Orders(market, true) {
+		if p.engine.Match(o, true) {
+			updates = append(updates, p.updateLocked(o))
+		}
+	}
+	p.mu.Unlock()
+
+	p.emit(updates)
+}
+
+// Refresh fetches the order book of market with GetOrderBook and applies it
+func (p *PaperTrader) Refresh(market string) error {
+	book := OrderBook{MarketSymbol: market, Depth: 25}
+	if err := p.b.GetOrderBook(&book); err != nil {
+		return err
+	}
+
+	p.Update(book)
+	return nil
+}
+
+// Follow applies the snapshots of l, kept in sync by LocalOrderBook.Run,
+// until ctx is cancelled. It returns ctx.Err().
+func (p *PaperTrader) Follow(ctx context.Context, l *LocalOrderBook) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.Changes():
+			if l.Synced() {
+				p.Update(l.Snapshot())
+			}
+		}
+	}
+}
+
+// NewOrder places a simulated order and returns it as the API would
+func (p *PaperTrader) NewOrder(order NewOrder) ([]byte, error) {
+	market := strings.ToUpper(order.MarketSymbol)
+
+	p.mu.Lock()
+	_, ok := p.engine.Books[market]
+	p.mu.Unlock()
+
+	if !ok {
+		if err := p.Refresh(market); err != nil {
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	o, err := p.place(order, market)
+	var updates []OrderUpdate
+	var r []byte
+	if err == nil {
+		updates = append(updates, p.updateLocked(o))
+		r, err = json.Marshal(paperOrder(o))
+	}
+	p.mu.Unlock()
+
+	p.emit(updates)
+	return r, err
+}
+
+// CancelOrder cancels a simulated order
+func (p *PaperTrader) CancelOrder(orderID string) ([]byte, error) {
+	p.mu.Lock()
+	o, ok := p.engine.Order(orderID)
+	if !ok {
+		p.mu.Unlock()
+		return nil, paperError(http.StatusNotFound, "NOT_FOUND")
+	}
+	if o.Status != ORDEROPEN {
+		p.mu.Unlock()
+		return nil, paperError(http.StatusConflict, "ORDER_NOT_OPEN")
+	}
+
+	p.engine.Close(o)
+	upd := p.updateLocked(o)
+	r, err := json.Marshal(paperOrder(o))
+	p.mu.Unlock()
+
+	p.emit([]OrderUpdate{upd})
+	return r, err
+}
+
+// ReplaceOrder cancels orderID and places the rest of newQty at newPrice.
+// Simulated orders cannot fill between the two steps.
+func (p *PaperTrader) ReplaceOrder(ctx context.Context, orderID string, newPrice, newQty decimal.Decimal) (ReplaceResult, error) {
+	return replaceOrder(ctx, p, orderID, newPrice, newQty)
+}
+
+// WaitForOrder waits until condition is met for a simulated order
+func (p *PaperTrader) WaitForOrder(ctx context.Context, orderID string, condition OrderCondition) (Order, error) {
+	for {
+		p.mu.Lock()
+		o, ok := p.engine.Order(orderID)
+		var cur Order
+		if ok {
+			cur = paperOrder(o)
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		if !ok {
+			return Order{}, paperError(http.StatusNotFound, "NOT_FOUND")
+		}
+
+		if done, err := orderDone(cur, condition); done {
+			return cur, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return Order{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// GetOpenOrders returns the open simulated orders of market, or of all markets when empty
+func (p *PaperTrader) GetOpenOrders(market string) ([]Order, error) {
+	return p.list(market, true), nil
+}
+
+// GetOrderHistory returns the closed simulated orders of market, newest first
+func (p *PaperTrader) GetOrderHistory(market string) ([]Order, error) {
+	return p.list(market, false), nil
+}
+
+// GetOrderHistoryPage returns a page of the closed simulated orders of market
+func (p *PaperTrader) GetOrderHistoryPage(market string, page HistoryPage) ([]Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	closed, err := p.engine.History(strings.ToUpper(market), matching.Page{
+		NextPageToken:     page.NextPageToken,
+		PreviousPageToken: page.PreviousPageToken,
+		PageSize:          page.PageSize,
+		StartDate:         page.StartDate,
+		EndDate:           page.EndDate,
+	})
+	if err != nil {
+		rerr := err.(*matching.Error)
+		return nil, paperError(rerr.Status, rerr.Code)
+	}
+
+	orders := []Order{}
+	for _, o := range closed {
+		orders = append(orders, paperOrder(o))
+	}
+
+	return orders, nil
+}
+
+// GetOrderHistoryByTag returns the closed simulated orders of market placed by strategy tag
+func (p *PaperTrader) GetOrderHistoryByTag(market, tag string) ([]Order, error) {
+	return OrdersByTag(p.list(market, false), tag), nil
+}
+
+// GetClosedOrderByClientOrderID looks up the closed simulated order of market
+// with clientOrderID. It returns ErrOrderNotFound when there is none.
+func (p *PaperTrader) GetClosedOrderByClientOrderID(market, clientOrderID string) (Order, error) {
+	for _, o := range p.list(market, false) {
+		if o.ClientOrderID == clientOrderID {
+			return o, nil
+		}
+	}
+
+	return Order{}, ErrOrderNotFound
+}
+
+// GetOrder returns a simulated order
+func (p *PaperTrader) GetOrder(orderID string) (Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	o, ok := p.engine.Order(orderID)
+	if !ok {
+		return Order{}, paperError(http.StatusNotFound, "NOT_FOUND")
+	}
+
+	return paperOrder(o), nil
+}
+
+// GetBalances returns the virtual balances, less what open orders hold in Available
+func (p *PaperTrader) GetBalances() ([]Balance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reserved := p.engine.Reserved()
+
+	balances := make([]Balance, 0, len(p.engine.Balances))
+	for c, total := range p.engine.Balances {
+		balances = append(balances, Balance{CurrencySymbol: c, Total: total, Available: total.Sub(reserved[c])})
+	}
+
+	sort.Slice(balances, func(i, j int) bool { return balances[i].CurrencySymbol < balances[j].CurrencySymbol })
+	return balances, nil
+}
+
+func (p *PaperTrader) list(market string, open bool) []Order {
+	market = strings.ToUpper(market)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	orders := []Order{}
+	for _, o := range p.engine.Orders(market, open) {
+		orders = append(orders, paperOrder(o))
+	}
+
+	return orders
+}
+
+// place validates, records and matches a new order
+func (p *PaperTrader) place(req NewOrder, market string) (*matching.Order, error) {
+	base, quote := marketCurrencies(market)
+	if quote == "" {
+		return nil, paperError(http.StatusBadRequest, "MARKET_DOES_NOT_EXIST")
+	}
+
+	p.setFees()
+	o, err := p.engine.Place(matching.Symbol{Symbol: market, Base: base, Quote: quote}, matching.Request{
+		Direction:     req.Direction,
+		Type:          req.Type,
+		Quantity:      req.Quantity,
+		Limit:         req.Limit,
+		TimeInForce:   req.TimeInForce,
+		ClientOrderID: req.ClientOrderID,
+	})
+	if err != nil {
+		rerr := err.(*matching.Error)
+		return nil, paperError(rerr.Status, rerr.Code)
+	}
+
+	return o, nil
+}
+
+// setFees hands the current fees to the engine
+func (p *PaperTrader) setFees() {
+	p.engine.MakerFee = p.MakerFee
+	p.engine.TakerFee = p.TakerFee
+}
+
+// paperOrder returns o as the API would
+func paperOrder(o *matching.Order) Order {
+	order := Order{
+		ID:            o.ID,
+		MarketSymbol:  o.MarketSymbol,
+		Direction:     o.Direction,
+		Type:          o.Type,
+		Quantity:      o.Quantity,
+		Limit:         o.Limit,
+		TimeInForce:   o.TimeInForce,
+		ClientOrderID: o.ClientOrderID,
+		FillQuantity:  o.FillQuantity,
+		Commission:    o.Commission,
+		Proceeds:      o.Proceeds,
+		Status:        o.Status,
+		CreatedAt:     jTime{o.CreatedAt},
+		Sequence:      o.Sequence,
+	}
+
+	if !o.UpdatedAt.IsZero() {
+		order.UpdatedAt = &jTime{o.UpdatedAt}
+	}
+	if !o.ClosedAt.IsZero() {
+		order.ClosedAt = &jTime{o.ClosedAt}
+	}
+
+	return order
+}
+
+// updateLocked returns the order stream message of a change of o and wakes
+// up WaitForOrder
+func (p *PaperTrader) updateLocked(o *matching.Order) OrderUpdate {
+	p.sequence++
+	o.Sequence = p.sequence
+
+	close(p.changed)
+	p.changed = make(chan struct{})
+
+	return OrderUpdate{AccountID: "paper", Sequence: p.sequence, Delta: paperOrder(o)}
+}
+
+func (p *PaperTrader) emit(updates []OrderUpdate) {
+	if p.OnOrderUpdate == nil {
+		return
+	}
+
+	for _, u := range updates {
+		p.OnOrderUpdate(u)
+	}
+}
+
+func paperLevels(deltas []OrderDelta) []matching.Level {
+	levels := make([]matching.Level, len(deltas))
+	for i, d := range deltas {
+		levels[i] = matching.Level{Rate: d.Rate, Quantity: d.Quantity}
+	}
+
+	return levels
+}
+
+// marketCurrencies splits a BASE-QUOTE market symbol
+func marketCurrencies(market string) (base, quote string) {
+	i := strings.IndexByte(market, '-')
+	if i <= 0 || i == len(market)-1 {
+		return market, ""
+	}
+
+	return market[:i], market[i+1:]
+}
+
+// paperError is the API error the exchange would return
+func paperError(status int, code string) *APIError {
+	return &APIError{StatusCode: status, Status: strconv.Itoa(status) + " " + http.StatusText(status), Code: code}
+}
@@ -0,0 +1,96 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPaperTrader(t *testing.T) {
+	d := decimal.RequireFromString
+
+	p := New("", "").NewPaperTrader(map[string]decimal.Decimal{"usd": d("1000")})
+	p.TakerFee = d("0.01")
+
+	var updates []OrderUpdate
+	p.OnOrderUpdate = func(u OrderUpdate) { updates = append(updates, u) }
+
+	p.Update(OrderBook{MarketSymbol: "BTC-USD",
+		BidDeltas: []OrderDelta{level("99", "1")},
+		AskDeltas: []OrderDelta{level("102", "2"), level("101", "1")}})
+
+	r, err := p.NewOrder(NewOrder{MarketSymbol: "BTC-USD", Direction: "BUY", Type: "LIMIT",
+		Quantity: "1.5", Limit: "101.5", TimeInForce: "GOOD_TIL_CANCELLED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o Order
+	if err := json.Unmarshal(r, &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != ORDEROPEN || !o.FillQuantity.Equal(d("1")) || !o.Commission.Equal(d("1.01")) {
+		t.Errorf("unexpected order %+v", o)
+	}
+
+	balances, _ := p.GetBalances()
+	if len(balances) != 2 || !balances[0].Total.Equal(d("1")) || !balances[1].Total.Equal(d("897.99")) ||
+		!balances[1].Available.Equal(d("847.24")) {
+		t.Errorf("unexpected balances %+v", balances)
+	}
+
+	filled := make(chan Order, 1)
+	go func() {
+		o, err := p.WaitForOrder(context.Background(), o.ID, UntilFilled)
+		if err != nil {
+			t.Error(err)
+		}
+		filled <- o
+	}()
+
+	// the market moves through the resting remainder, filled as maker
+	p.Update(OrderBook{MarketSymbol: "BTC-USD", AskDeltas: []OrderDelta{level("100", "5")}})
+
+	select {
+	case o = <-filled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("order not filled")
+	}
+	if !o.Proceeds.Equal(d("151.75")) || !o.Commission.Equal(d("1.01")) {
+		t.Errorf("unexpected fill %+v", o)
+	}
+
+	if len(updates) != 2 || updates[1].Sequence <= updates[0].Sequence || updates[1].Delta.Status != ORDERCLOSED {
+		t.Errorf("unexpected updates %+v", updates)
+	}
+
+	var apiErr *APIError
+	if _, err := p.CancelOrder(o.ID); !errors.As(err, &apiErr) || apiErr.Code != "ORDER_NOT_OPEN" {
+		t.Errorf("closed order cancelled: %v", err)
+	}
+
+	_, err = p.NewOrder(NewOrder{MarketSymbol: "BTC-USD", Direction: "SELL", Type: "MARKET", Quantity: "3"})
+	if !errors.As(err, &apiErr) || apiErr.Code != "INSUFFICIENT_FUNDS" {
+		t.Errorf("oversized sell accepted: %v", err)
+	}
+
+	if history, _ := p.GetOrderHistory("btc-usd"); len(history) != 1 {
+		t.Errorf("unexpected history %+v", history)
+	}
+
+	if page, err := p.GetOrderHistoryPage("btc-usd", HistoryPage{NextPageToken: o.ID}); err != nil || len(page) != 0 {
+		t.Errorf("unexpected page after the last order %+v %v", page, err)
+	}
+
+	if _, err := p.GetOrderHistoryPage("", HistoryPage{PageSize: MaxPageSize + 1}); !errors.As(err, &apiErr) || apiErr.Code != "INVALID_PAGE_SIZE" {
+		t.Errorf("oversized page accepted: %v", err)
+	}
+
+	if _, err := p.GetClosedOrderByClientOrderID("btc-usd", "unknown"); err != ErrOrderNotFound {
+		t.Errorf("unknown order found: %v", err)
+	}
+}
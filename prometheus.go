@@ -0,0 +1,227 @@
+package bittrex
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the REST latency histogram buckets in seconds
+var DefaultLatencyBuckets = []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics is a Metrics implementation which renders the
+// Prometheus text exposition format. It is an http.Handler.
+type PrometheusMetrics struct {
+	mu sync.Mutex
+
+	requests    *counterVec
+	latency     *histogramVec
+	messages    *counterVec
+	dropped     *counterVec
+	connects    *counterVec
+	disconnects *counterVec
+	reconnects  *counterVec
+}
+
+// NewPrometheusMetrics returns PrometheusMetrics using DefaultLatencyBuckets
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		requests: newCounterVec("bittrex_http_requests_total",
+			"Total REST requests by endpoint, method, status and API error code.",
+			"endpoint", "method", "status", "code"),
+		latency: newHistogramVec("bittrex_http_request_duration_seconds",
+			"REST request latency by endpoint and method.",
+			DefaultLatencyBuckets, "endpoint", "method"),
+		messages: newCounterVec("bittrex_ws_messages_total",
+			"WebSocket messages received by stream and hub method.",
+			"stream", "method"),
+		dropped: newCounterVec("bittrex_ws_dropped_total",
+			"WebSocket messages dropped because the consumer was too slow.",
+			"stream", "method"),
+		connects: newCounterVec("bittrex_ws_connects_total",
+			"WebSocket hub connections.",
+			"stream"),
+		disconnects: newCounterVec("bittrex_ws_disconnects_total",
+			"WebSocket disconnections and heartbeat timeouts.",
+			"stream"),
+		reconnects: newCounterVec("bittrex_ws_reconnects_total",
+			"Successful WebSocket reconnections of supervised streams.",
+			"stream"),
+	}
+}
+
+// ObserveRequest implements Metrics
+func (p *PrometheusMetrics) ObserveRequest(endpoint, method string, status int, code string, duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests.inc(endpoint, method, strconv.Itoa(status), code)
+	p.latency.observe(duration.Seconds(), endpoint, method)
+}
+
+// StreamMessage implements Metrics
+func (p *PrometheusMetrics) StreamMessage(stream, method string) {
+	p.mu.Lock()
+	p.messages.inc(stream, method)
+	p.mu.Unlock()
+}
+
+// StreamDropped implements Metrics
+func (p *PrometheusMetrics) StreamDropped(stream, method string) {
+	p.mu.Lock()
+	p.dropped.inc(stream, method)
+	p.mu.Unlock()
+}
+
+// StreamConnect implements Metrics
+func (p *PrometheusMetrics) StreamConnect(stream string) {
+	p.mu.Lock()
+	p.connects.inc(stream)
+	p.mu.Unlock()
+}
+
+// StreamDisconnect implements Metrics
+func (p *PrometheusMetrics) StreamDisconnect(stream string) {
+	p.mu.Lock()
+	p.disconnects.inc(stream)
+	p.mu.Unlock()
+}
+
+// StreamReconnect implements Metrics
+func (p *PrometheusMetrics) StreamReconnect(stream string) {
+	p.mu.Lock()
+	p.reconnects.inc(stream)
+	p.mu.Unlock()
+}
+
+// WriteTo writes all metrics in the text exposition format
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var sb strings.Builder
+	p.requests.write(&sb)
+	p.latency.write(&sb)
+	p.messages.write(&sb)
+	p.dropped.write(&sb)
+	p.connects.write(&sb)
+	p.disconnects.write(&sb)
+	p.reconnects.write(&sb)
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// ServeHTTP implements http.Handler
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+}
+
+func (c *counterVec) inc(values ...string) {
+	c.values[labelString(c.labels, values)]++
+}
+
+func (c *counterVec) write(sb *strings.Builder) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, k := range sortedKeys(c.values) {
+		fmt.Fprintf(sb, "%s{%s} %s\n", c.name, k, formatFloat(c.values[k]))
+	}
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	values  map[string]*histogram
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, values: map[string]*histogram{}}
+}
+
+func (h *histogramVec) observe(v float64, values ...string) {
+	k := labelString(h.labels, values)
+
+	hist, ok := h.values[k]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[k] = hist
+	}
+
+	for i, le := range h.buckets {
+		if v <= le {
+			hist.counts[i]++
+		}
+	}
+	hist.sum += v
+	hist.count++
+}
+
+func (h *histogramVec) write(sb *strings.Builder) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		hist := h.values[k]
+		for i, le := range h.buckets {
+			fmt.Fprintf(sb, "%s_bucket{%s,le=\"%s\"} %d\n", h.name, k, formatFloat(le), hist.counts[i])
+		}
+		fmt.Fprintf(sb, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.name, k, hist.count)
+		fmt.Fprintf(sb, "%s_sum{%s} %s\n", h.name, k, formatFloat(hist.sum))
+		fmt.Fprintf(sb, "%s_count{%s} %d\n", h.name, k, hist.count)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelString(names, values []string) string {
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = n + `="` + labelEscaper.Replace(values[i]) + `"`
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,31 @@
+package bittrex
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	p := NewPrometheusMetrics()
+	p.ObserveRequest("orders", "POST", 429, "", 30*time.Millisecond)
+	p.StreamDropped(ORDERBOOK, `order"book`)
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`bittrex_http_requests_total{endpoint="orders",method="POST",status="429",code=""} 1`,
+		`bittrex_http_request_duration_seconds_bucket{endpoint="orders",method="POST",le="0.025"} 0`,
+		`bittrex_http_request_duration_seconds_bucket{endpoint="orders",method="POST",le="0.05"} 1`,
+		`bittrex_http_request_duration_seconds_count{endpoint="orders",method="POST"} 1`,
+		`bittrex_ws_dropped_total{stream="orderBook",method="order\"book"} 1`,
+		"# TYPE bittrex_ws_connects_total counter",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrReplaceFilled is returned by ReplaceOrder when the fills of the
+// cancelled order leave nothing to place
+var ErrReplaceFilled = errors.New("order filled before it could be replaced")
+
+// ReplaceStage is the step of ReplaceOrder which failed
+type ReplaceStage int
+
+// ReplaceOrder stages
+const (
+	ReplaceCancel ReplaceStage = iota
+	ReplaceReadFill
+	ReplacePlace
+)
+
+func (s ReplaceStage) String() string {
+	switch s {
+	case ReplaceCancel:
+		return "cancel"
+	case ReplaceReadFill:
+		return "read fill"
+	case ReplacePlace:
+		return "place"
+	}
+
+	return "unknown"
+}
+
+// ReplaceError reports the step of ReplaceOrder which failed. Cancelled tells
+// whether the original order was cancelled before the failure.
+type ReplaceError struct {
+	Stage     ReplaceStage
+	OrderID   string
+	Cancelled bool
+	Err       error
+}
+
+func (e *ReplaceError) Error() string {
+	return fmt.Sprintf("replace order %s: %s: %v", e.OrderID, e.Stage, e.Err)
+}
+
+func (e *ReplaceError) Unwrap() error {
+	return e.Err
+}
+
+// ReplaceResult is the outcome of ReplaceOrder
+type ReplaceResult struct {
+	// Cancelled is the original order in its closed state
+	Cancelled Order
+	// Replacement is the new order, zero when it was not placed
+	Replacement Order
+	// Quantity is newQty less the quantity filled on the original order
+	Quantity decimal.Decimal
+}
+
+// ReplaceOrder cancels the limit order orderID and places a new one at
+// newPrice. newQty is the total quantity wanted, the quantity filled on the
+// cancelled order is subtracted from it. The replacement ClientOrderID is
+// derived from orderID and keeps the strategy tag of the original one, so a
+// retry of the same replacement is rejected by the exchange instead of
+// placing a second order.
+func (b *Bittrex) ReplaceOrder(ctx context.Context, orderID string, newPrice, newQty decimal.Decimal) (ReplaceResult, error) {
+	return replaceOrder(ctx, b, orderID, newPrice, newQty)
+}
+
+// replaceOrder is ReplaceOrder made with the calls of t
+func replaceOrder(ctx context.Context, t Trading, orderID string, newPrice, newQty decimal.Decimal) (res ReplaceResult, err error) {
+	r, err := t.CancelOrder(orderID)
+	if err != nil {
+		return res, &ReplaceError{Stage: ReplaceCancel, OrderID: orderID, Err: err}
+	}
+
+	// the cancel may be answered before the order is closed, or with a body
+	// which cannot be decoded. The fill quantity is final only once it is
+	// closed.
+	if json.Unmarshal(r, &res.Cancelled) != nil || res.Cancelled.Status != ORDERCLOSED {
+		res.Cancelled, err = t.WaitForOrder(ctx, orderID, UntilClosed)
+		if err != nil {
+			return res, &ReplaceError{Stage: ReplaceReadFill, OrderID: orderID, Cancelled: true, Err: err}
+		}
+	}
+
+	res.Quantity = newQty.Sub(res.Cancelled.FillQuantity)
+	if !res.Quantity.IsPositive() {
+		return res, &ReplaceError{Stage: ReplacePlace, OrderID: orderID, Cancelled: true, Err: ErrReplaceFilled}
+	}
+
+	order := NewOrder{
+		MarketSymbol:  res.Cancelled.MarketSymbol,
+		Direction:     res.Cancelled.Direction,
+		Type:          res.Cancelled.Type,
+		Quantity:      res.Quantity.String(),
+		Limit:         newPrice.String(),
+		TimeInForce:   res.Cancelled.TimeInForce,
+		ClientOrderID: replaceClientOrderID(res.Cancelled),
+	}
+
+	r, err = t.NewOrder(order)
+	if err == nil {
+		err = json.Unmarshal(r, &res.Replacement)
+	}
+	if err != nil {
+		return res, &ReplaceError{Stage: ReplacePlace, OrderID: orderID, Cancelled: true, Err: err}
+	}
+
+	return res, nil
+}
+
+// replaceClientOrderID derives the client order ID of the order replacing o.
+// The strategy tag of a tagged ID is kept, the counter is taken from the
+// derived UUID.
+func replaceClientOrderID(o Order) string {
+	u := uuid.NewSHA1(uuid.NameSpaceOID, []byte("bittrex-replace:"+o.ID))
+
+	if id, err := ParseClientOrderID(o.ClientOrderID); err == nil {
+		var counter uint64
+		for _, c := range u[8:] {
+			counter = counter<<8 | uint64(c)
+		}
+
+		id.Counter = counter
+		return id.String()
+	}
+
+	return u.String()
+}
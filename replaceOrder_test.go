@@ -0,0 +1,112 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// redirect sends the requests of the returned client to srv
+func redirect(srv *httptest.Server) *http.Client {
+	u, _ := url.Parse(srv.URL)
+
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme = u.Scheme
+		req.URL.Host = u.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestReplaceOrder(t *testing.T) {
+	var placed NewOrder
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "DELETE" && r.URL.Path == "/v3/orders/o1":
+			w.Write([]byte(`{"id":"o1","marketSymbol":"BTC-USD","direction":"BUY","type":"LIMIT",
+				"quantity":"1","limit":"100","timeInForce":"GOOD_TIL_CANCELLED","fillQuantity":"0.4","status":"CLOSED"}`))
+		case r.Method == "DELETE" && r.URL.Path == "/v3/orders/o4":
+			w.Write([]byte(`{"id":`))
+		case r.Method == "GET" && r.URL.Path == "/v3/orders/o4":
+			w.Write([]byte(`{"id":"o4","marketSymbol":"BTC-USD","direction":"SELL","type":"LIMIT",
+				"quantity":"1","limit":"100","timeInForce":"GOOD_TIL_CANCELLED","fillQuantity":"0.4","status":"CLOSED"}`))
+		case r.Method == "POST" && r.URL.Path == "/v3/orders":
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &placed)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"o2","status":"OPEN","quantity":"` + placed.Quantity + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	b := NewWithCustomHTTPClient("key", "secret", redirect(srv))
+
+	res, err := b.ReplaceOrder(context.Background(), "o1", decimal.NewFromInt(101), decimal.NewFromInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Replacement.ID != "o2" || !res.Quantity.Equal(decimal.RequireFromString("0.6")) {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	if placed.Quantity != "0.6" || placed.Limit != "101" || placed.Direction != "BUY" ||
+		placed.ClientOrderID != replaceClientOrderID(res.Cancelled) {
+		t.Errorf("unexpected replacement %+v", placed)
+	}
+
+	_, err = b.ReplaceOrder(context.Background(), "o1", decimal.NewFromInt(101), decimal.RequireFromString("0.4"))
+	var rerr *ReplaceError
+	if !errors.As(err, &rerr) || rerr.Stage != ReplacePlace || !rerr.Cancelled || !errors.Is(err, ErrReplaceFilled) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	_, err = b.ReplaceOrder(context.Background(), "o3", decimal.NewFromInt(101), decimal.NewFromInt(1))
+	if !errors.As(err, &rerr) || rerr.Stage != ReplaceCancel || rerr.Cancelled {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// the fill of a cancel answered with an unreadable body is read back
+	res, err = replaceOrder(context.Background(), restTrading{b}, "o4", decimal.NewFromInt(99), decimal.NewFromInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Cancelled.ID != "o4" || res.Replacement.ID != "o2" || placed.Quantity != "0.6" || placed.Direction != "SELL" {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+// restTrading waits for orders with a single GetOrder instead of a stream
+type restTrading struct {
+	*Bittrex
+}
+
+func (r restTrading) WaitForOrder(ctx context.Context, orderID string, condition OrderCondition) (Order, error) {
+	o, err := r.GetOrder(orderID)
+	if err != nil {
+		return Order{}, err
+	}
+
+	if done, err := orderDone(o, condition); !done {
+		return Order{}, errors.New("order not done")
+	} else if err != nil {
+		return Order{}, err
+	}
+
+	return o, nil
+}
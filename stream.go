@@ -0,0 +1,924 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thebotguys/signalr"
+)
+
+// ErrStreamClosed is returned by a Stream which was closed by the caller
+var ErrStreamClosed = errors.New("stream closed")
+
+// StreamState is the connection state reported by a supervised Stream
+type StreamState int
+
+const (
+	// StreamConnecting is reported before the first connection attempt
+	StreamConnecting StreamState = iota
+	// StreamConnected is reported once the hub is connected and subscribed
+	StreamConnected
+	// StreamReconnecting is reported with the cause when the connection is lost
+	StreamReconnecting
+	// StreamClosed is reported when Run returns
+	StreamClosed
+)
+
+func (st StreamState) String() string {
+	switch st {
+	case StreamConnecting:
+		return "connecting"
+	case StreamConnected:
+		return "connected"
+	case StreamReconnecting:
+		return "reconnecting"
+	case StreamClosed:
+		return "closed"
+	}
+
+	return "unknown"
+}
+
+// AuthState is the authentication state reported by a Stream
+type AuthState int
+
+const (
+	// AuthAuthenticated is reported after every successful authentication
+	AuthAuthenticated AuthState = iota
+	// AuthExpiring is reported when the hub announces the authentication expires
+	AuthExpiring
+	// AuthFailed is reported with the cause when authentication fails. It is
+	// retried with backoff while the connection is up, also when it fails in
+	// Connect.
+	AuthFailed
+)
+
+func (st AuthState) String() string {
+	switch st {
+	case AuthAuthenticated:
+		return "authenticated"
+	case AuthExpiring:
+		return "expiring"
+	case AuthFailed:
+		return "failed"
+	}
+
+	return "unknown"
+}
+
+// StreamMessage is a decoded message received on a hub channel
+type StreamMessage struct {
+	// Channel is the channel the message belongs to, e.g. "ticker_BTC-USD"
+	Channel string
+	// Method is the hub method, e.g. TICKER
+	Method string
+	// Data is the decompressed JSON payload
+	Data json.RawMessage
+}
+
+// StreamHandler receives the messages of a Subscription. Handlers are called
+// from the connection's read loop and must not block.
+type StreamHandler func(msg StreamMessage)
+
+// Stream keeps a single SignalR connection to WSHUB and multiplexes any
+// number of channel subscriptions over it. Its typed Subscribe methods share
+// the connection, which is kept up by Run or Connect. They do not end when it
+// is lost.
+type Stream struct {
+	// Name labels the metrics of the stream, "default" when empty. The
+	// dedicated streams of the client, like those of the typed subscriptions
+	// of Bittrex or of Run with a nil Stream, are named after the hub method
+	// they follow. Keep the set of names small.
+	Name string
+	// HeartbeatTimeout closes the connection when no message was received in
+	// the given interval. One minute is used when it is not positive.
+	HeartbeatTimeout time.Duration
+	// OnError is called with messages that could not be decoded or routed.
+	// The OnStreamError hooks of the client are called as well.
+	OnError func(err error)
+	// OnState is called on every connection state change of Run
+	OnState func(state StreamState, err error)
+	// OnGap is called by Run after a reconnect. Messages may have been missed
+	// while disconnected and consumers should resync from the REST API.
+	OnGap func()
+	// MinBackoff and MaxBackoff bound the exponential delay between reconnects
+	// and authentication retries. One second and one minute are used when they
+	// are not positive.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnAuth is called on every authentication state change
+	OnAuth func(state AuthState, err error)
+	// Backpressure is the overflow policy of the typed subscriptions made on
+	// the stream. NewStream sets the one of the client.
+	Backpressure Backpressure
+
+	b *Bittrex
+	// owned streams are connected and closed by the typed subscription made on them
+	owned bool
+
+	mu            sync.Mutex
+	client        *signalr.Client
+	subs          map[string]map[*Subscription]struct{}
+	done          chan struct{}
+	err           error
+	stop          chan struct{}
+	authenticated bool
+	authExpiring  chan struct{}
+	authRetry     chan struct{}
+	// authPending is set while the private channels wait for authentication
+	authPending bool
+
+	// callMu serialises hub calls, signalr.Client is not safe for concurrent writes
+	callMu sync.Mutex
+
+	lastMessage int64
+	dropped     uint64
+}
+
+// Subscription is a handler registered for a set of channels of a Stream
+type Subscription struct {
+	stream   *Stream
+	channels []string
+	handler  StreamHandler
+}
+
+// SubscribeError is returned when the hub rejects channels of a Subscribe or
+// Unsubscribe call
+type SubscribeError struct {
+	Method string
+	// Channels maps every rejected channel to the hub error code
+	Channels map[string]string
+}
+
+func (e *SubscribeError) Error() string {
+	failed := make([]string, 0, len(e.Channels))
+	for ch, code := range e.Channels {
+		failed = append(failed, ch+": "+code)
+	}
+	sort.Strings(failed)
+
+	return e.Method + " rejected " + strings.Join(failed, ", ")
+}
+
+// hubResponse is the per channel result of the Subscribe and Unsubscribe hub methods
+type hubResponse struct {
+	Success   bool   `json:"Success"`
+	ErrorCode string `json:"ErrorCode"`
+}
+
+// Stream defaults, also used in place of fields which are not positive
+const (
+	defaultHeartbeatTimeout = time.Minute
+	defaultMinBackoff       = time.Second
+	defaultMaxBackoff       = time.Minute
+)
+
+// NewStream returns a Stream which is not connected yet
+func (b *Bittrex) NewStream() *Stream {
+	return &Stream{
+		HeartbeatTimeout: defaultHeartbeatTimeout,
+		MinBackoff:       defaultMinBackoff,
+		MaxBackoff:       defaultMaxBackoff,
+		Backpressure:     b.backpressure,
+		authExpiring:     make(chan struct{}, 1),
+		authRetry:        make(chan struct{}, 1),
+		b:                b,
+		subs:             map[string]map[*Subscription]struct{}{},
+	}
+}
+
+// Connect dials the hub and subscribes to the heartbeat channel and to the
+// channels of all registered subscriptions, authenticating first if
+// Authenticate was called before. When authentication fails it is retried
+// with backoff and the private channels are subscribed once it succeeds.
+// The connection is closed when it is lost, on heartbeat timeout or on Close.
+//
+// When the hub rejects some of the channels they are removed from their
+// subscriptions and a *SubscribeError is returned while the connection stays
+// open. The connection is only closed when none of the channels was accepted.
+func (s *Stream) Connect() error {
+	const timeout = 5 * time.Second
+
+	if _, err := s.connected(); err == nil {
+		return errors.New("stream is already connected")
+	}
+
+	client := signalr.NewWebsocketClient()
+
+	client.OnClientMethod = func(hub string, method string, messages []json.RawMessage) {
+		if hub != WSHUB {
+			return
+		}
+
+		atomic.StoreInt64(&s.lastMessage, time.Now().UnixNano())
+
+		if method == AUTHEXPIRED {
+			// hub calls must not be made from the read loop
+			select {
+			case s.authExpiring <- struct{}{}:
+			default:
+			}
+			return
+		}
+
+		for _, msg := range messages {
+			s.dispatch(method, msg)
+		}
+	}
+
+	client.OnMessageError = s.error
+
+	err := doAsyncTimeout(
+		func() error {
+			return client.Connect("https", s.b.wsHost(), []string{WSHUB})
+		}, func(err error) {
+			if err == nil {
+				client.Close()
+			}
+		}, timeout)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+
+	s.mu.Lock()
+	s.client = client
+	s.done = done
+	s.err = nil
+	s.authPending = false
+	s.mu.Unlock()
+
+	atomic.StoreInt64(&s.lastMessage, time.Now().UnixNano())
+	s.b.metrics.StreamConnect(s.label())
+
+	go s.watch(client, done)
+
+	s.mu.Lock()
+	auth := s.authenticated
+	s.mu.Unlock()
+
+	channels := s.channels()
+
+	if auth {
+		if err := s.Authenticate(); err != nil {
+			s.mu.Lock()
+			s.authPending = true
+			s.mu.Unlock()
+
+			select {
+			case s.authRetry <- struct{}{}:
+			default:
+			}
+
+			channels = publicChannels(channels)
+		}
+	}
+
+	_, err = s.call("Subscribe", append([]string{HEARTBEAT}, channels...))
+
+	var serr *SubscribeError
+	if errors.As(err, &serr) {
+		s.reject(serr.Channels)
+		if _, ok := serr.Channels[HEARTBEAT]; !ok && len(serr.Channels) < len(channels) {
+			return err
+		}
+	}
+
+	if err != nil {
+		s.closeWithError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Authenticate authenticates the connection for private channels.
+// The stream authenticates again when the hub sends authenticationExpiring
+// and on every following Connect. Called before Connect it only marks the
+// stream as authenticated.
+func (s *Stream) Authenticate() error {
+	client, err := s.connected()
+	if err != nil {
+		s.mu.Lock()
+		s.authenticated = true
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.callMu.Lock()
+	err = s.b.Authentication(client)
+	s.callMu.Unlock()
+	if err != nil {
+		s.authState(AuthFailed, err)
+		return err
+	}
+
+	s.mu.Lock()
+	s.authenticated = true
+	s.mu.Unlock()
+
+	s.authState(AuthAuthenticated, nil)
+	return nil
+}
+
+// Run connects the stream and keeps it connected until ctx is cancelled or
+// Close is called. Lost connections are reconnected with exponential backoff,
+// authenticated again and all subscriptions are replayed. Run returns
+// ctx.Err() or ErrStreamClosed.
+func (s *Stream) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return errors.New("stream is already running")
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.mu.Unlock()
+
+	minBackoff, maxBackoff := backoffs(s.MinBackoff, s.MaxBackoff)
+	backoff := minBackoff
+	reconnect := false
+
+	defer func() {
+		s.mu.Lock()
+		if s.stop == stop {
+			s.stop = nil
+		}
+		s.mu.Unlock()
+	}()
+
+	s.state(StreamConnecting, nil)
+
+	for {
+		err := s.Connect()
+		if _, cerr := s.connected(); err != nil && cerr == nil {
+			// some channels were rejected, the others are subscribed
+			s.error(err)
+			err = nil
+		}
+		if err == nil {
+			s.state(StreamConnected, nil)
+			if reconnect {
+				s.b.metrics.StreamReconnect(s.label())
+				if s.OnGap != nil {
+					s.OnGap()
+				}
+			}
+			backoff = minBackoff
+
+			select {
+			case <-s.Done():
+				err = s.Err()
+			case <-stop:
+			case <-ctx.Done():
+			}
+		}
+
+		select {
+		case <-stop:
+			s.closeWithError(ErrStreamClosed)
+			s.state(StreamClosed, ErrStreamClosed)
+			return ErrStreamClosed
+		case <-ctx.Done():
+			s.closeWithError(ctx.Err())
+			s.state(StreamClosed, ctx.Err())
+			return ctx.Err()
+		default:
+		}
+
+		reconnect = true
+		s.state(StreamReconnecting, err)
+
+		select {
+		case <-stop:
+			s.state(StreamClosed, ErrStreamClosed)
+			return ErrStreamClosed
+		case <-ctx.Done():
+			s.state(StreamClosed, ctx.Err())
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// Subscribe registers handler for channels and subscribes the hub to the
+// channels which had no subscribers yet, in a single hub call. Subscriptions
+// made before Connect are subscribed when the stream connects.
+//
+// When the hub rejects some of the channels Subscribe returns a
+// *SubscribeError and the subscription for the accepted channels, or nil if
+// none was accepted.
+func (s *Stream) Subscribe(handler StreamHandler, channels ...string) (*Subscription, error) {
+	sub := &Subscription{stream: s, channels: channels, handler: handler}
+
+	var fresh []string
+
+	s.mu.Lock()
+	for _, ch := range channels {
+		key := channelKey(ch)
+		if len(s.subs[key]) == 0 {
+			s.subs[key] = map[*Subscription]struct{}{}
+			fresh = append(fresh, ch)
+		}
+		s.subs[key][sub] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	if _, err := s.connected(); err != nil || len(fresh) == 0 {
+		return sub, nil
+	}
+
+	_, err := s.call("Subscribe", fresh)
+
+	var serr *SubscribeError
+	if errors.As(err, &serr) {
+		if s.drop(sub, serr.Channels) {
+			return sub, err
+		}
+		return nil, err
+	}
+
+	if err != nil {
+		s.remove(sub)
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Unsubscribe removes the subscription and unsubscribes the hub from the
+// channels which have no subscribers left.
+func (sub *Subscription) Unsubscribe() error {
+	unused := sub.stream.remove(sub)
+	if _, err := sub.stream.connected(); err != nil || len(unused) == 0 {
+		return nil
+	}
+
+	_, err := sub.stream.call("Unsubscribe", unused)
+	return err
+}
+
+// Channels returns the channels of the subscription
+func (sub *Subscription) Channels() []string {
+	sub.stream.mu.Lock()
+	defer sub.stream.mu.Unlock()
+
+	return append([]string(nil), sub.channels...)
+}
+
+// Close closes the connection and stops Run. Subscriptions are kept and are
+// subscribed again by the next Connect.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.mu.Unlock()
+
+	s.closeWithError(ErrStreamClosed)
+}
+
+// Done is closed when the connection ends
+func (s *Stream) Done() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.done
+}
+
+// Err returns the reason the connection ended, or nil while it is running
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+func (s *Stream) connected() (*signalr.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil, errors.New("stream is not connected")
+	}
+
+	return s.client, nil
+}
+
+// call invokes a hub method taking a list of channels and checks the result
+// of every channel.
+func (s *Stream) call(method string, channels []string) ([]hubResponse, error) {
+	client, err := s.connected()
+	if err != nil {
+		return nil, err
+	}
+
+	s.callMu.Lock()
+	raw, err := client.CallHub(WSHUB, method, channels)
+	s.callMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []hubResponse
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	failed := map[string]string{}
+	for i, ch := range channels {
+		switch {
+		case i >= len(res):
+			failed[ch] = "NO_RESPONSE"
+		case !res[i].Success:
+			failed[ch] = res[i].ErrorCode
+		}
+	}
+
+	if len(failed) > 0 {
+		return res, &SubscribeError{Method: method, Channels: failed}
+	}
+
+	return res, nil
+}
+
+// receiving reports whether the channels of sub are subscribed on the
+// current connection
+func (s *Stream) receiving(sub *Subscription) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil || len(sub.channels) == 0 {
+		return false
+	}
+
+	if s.authPending {
+		for _, ch := range sub.channels {
+			if isPrivate(ch) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// drop removes the rejected channels from sub and reports whether it has
+// channels left
+func (s *Stream) drop(sub *Subscription, rejected map[string]string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var kept []string
+	for _, ch := range sub.channels {
+		if _, ok := rejected[ch]; !ok {
+			kept = append(kept, ch)
+			continue
+		}
+
+		key := channelKey(ch)
+		delete(s.subs[key], sub)
+		if len(s.subs[key]) == 0 {
+			delete(s.subs, key)
+		}
+	}
+	sub.channels = kept
+
+	return len(kept) > 0
+}
+
+// reject removes the rejected channels from all subscriptions
+func (s *Stream) reject(rejected map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range rejected {
+		key := channelKey(ch)
+		for sub := range s.subs[key] {
+			var kept []string
+			for _, c := range sub.channels {
+				if channelKey(c) != key {
+					kept = append(kept, c)
+				}
+			}
+			sub.channels = kept
+		}
+		delete(s.subs, key)
+	}
+}
+
+// channels returns the channels of all subscriptions
+func (s *Stream) channels() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := map[string]bool{}
+	var channels []string
+
+	for _, subs := range s.subs {
+		for sub := range subs {
+			for _, ch := range sub.channels {
+				if !seen[channelKey(ch)] {
+					seen[channelKey(ch)] = true
+					channels = append(channels, ch)
+				}
+			}
+		}
+	}
+
+	return channels
+}
+
+// remove drops sub and returns the channels left without subscribers
+func (s *Stream) remove(sub *Subscription) (unused []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ch := range sub.channels {
+		key := channelKey(ch)
+		if _, ok := s.subs[key][sub]; !ok {
+			continue
+		}
+
+		delete(s.subs[key], sub)
+		if len(s.subs[key]) == 0 {
+			delete(s.subs, key)
+			unused = append(unused, ch)
+		}
+	}
+
+	return unused
+}
+
+func (s *Stream) dispatch(method string, msg json.RawMessage) {
+	var data json.RawMessage
+	if err := DecodeMessage(msg, &data); err != nil {
+		s.error(fmt.Errorf("%s: %w", method, err))
+		return
+	}
+
+	channel, err := channelOf(method, data)
+	if err != nil {
+		s.error(err)
+		return
+	}
+
+	s.b.metrics.StreamMessage(s.label(), method)
+
+	m := StreamMessage{Channel: channel, Method: method, Data: data}
+	s.b.streamMessage(m)
+
+	s.mu.Lock()
+	handlers := make([]StreamHandler, 0, len(s.subs[channelKey(channel)]))
+	for sub := range s.subs[channelKey(channel)] {
+		handlers = append(handlers, sub.handler)
+	}
+	s.mu.Unlock()
+
+	for _, h := range handlers {
+		h(m)
+	}
+}
+
+func (s *Stream) watch(client *signalr.Client, done chan struct{}) {
+	timeout := s.heartbeatTimeout()
+	interval := timeout / 4
+	if interval <= 0 {
+		interval = timeout
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	var retry <-chan time.Time
+	minBackoff, maxBackoff := backoffs(s.MinBackoff, s.MaxBackoff)
+	backoff := minBackoff
+
+	reauthenticate := func() {
+		if err := s.Authenticate(); err != nil {
+			retry = time.After(backoff)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			return
+		}
+
+		retry = nil
+		backoff = minBackoff
+		s.subscribePending()
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-s.authExpiring:
+			s.authState(AuthExpiring, nil)
+			reauthenticate()
+		case <-s.authRetry:
+			retry = time.After(backoff)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		case <-retry:
+			reauthenticate()
+		case <-client.DisconnectedChannel:
+			s.b.metrics.StreamDisconnect(s.label())
+			s.closeWithError(errors.New("client.DisconnectedChannel"))
+			return
+		case <-tick.C:
+			last := time.Unix(0, atomic.LoadInt64(&s.lastMessage))
+			if time.Since(last) > timeout {
+				s.b.metrics.StreamDisconnect(s.label())
+				s.closeWithError(errors.New("heartbeat timeout"))
+				return
+			}
+		}
+	}
+}
+
+// heartbeatTimeout returns HeartbeatTimeout, or its default when not positive
+func (s *Stream) heartbeatTimeout() time.Duration {
+	if s.HeartbeatTimeout <= 0 {
+		return defaultHeartbeatTimeout
+	}
+
+	return s.HeartbeatTimeout
+}
+
+// backoffs returns min and max, or their defaults when not positive. The
+// maximum is never below the minimum.
+func backoffs(min, max time.Duration) (time.Duration, time.Duration) {
+	if min <= 0 {
+		min = defaultMinBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+	if max < min {
+		max = min
+	}
+
+	return min, max
+}
+
+// subscribePending subscribes the private channels held back by Connect
+// while the connection was not authenticated
+func (s *Stream) subscribePending() {
+	s.mu.Lock()
+	pending := s.authPending
+	s.authPending = false
+	s.mu.Unlock()
+
+	if !pending {
+		return
+	}
+
+	var private []string
+	for _, ch := range s.channels() {
+		if isPrivate(ch) {
+			private = append(private, ch)
+		}
+	}
+
+	if len(private) == 0 {
+		return
+	}
+
+	_, err := s.call("Subscribe", private)
+
+	var serr *SubscribeError
+	if errors.As(err, &serr) {
+		s.reject(serr.Channels)
+	}
+
+	if err != nil {
+		s.error(err)
+	}
+}
+
+func (s *Stream) closeWithError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return
+	}
+
+	s.client.Close()
+	s.client = nil
+	s.err = err
+	close(s.done)
+}
+
+func (s *Stream) state(state StreamState, err error) {
+	if s.OnState != nil {
+		s.OnState(state, err)
+	}
+}
+
+func (s *Stream) authState(state AuthState, err error) {
+	if s.OnAuth != nil {
+		s.OnAuth(state, err)
+	}
+}
+
+func (s *Stream) error(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+
+	s.b.streamError(err)
+}
+
+// channelOf returns the channel a decoded message of the hub method belongs to
+func channelOf(method string, data []byte) (string, error) {
+	var p struct {
+		Symbol       string `json:"symbol"`
+		MarketSymbol string `json:"marketSymbol"`
+		Depth        int    `json:"depth"`
+		Interval     string `json:"interval"`
+	}
+
+	switch method {
+	case HEARTBEAT, ORDER, BALANCE, DEPOSIT, EXECUTION:
+		return method, nil
+	case CONDITIONALORDER:
+		return "conditional_order", nil
+	case MARKETSUMMARIES:
+		return "market_summaries", nil
+	case TICKERS:
+		return "tickers", nil
+	case ORDERBOOK, TICKER, TRADE, CANDLE, MARKETSUMMARY:
+		if err := json.Unmarshal(data, &p); err != nil {
+			return "", fmt.Errorf("%s: %s", method, err)
+		}
+	}
+
+	switch method {
+	case ORDERBOOK:
+		return "orderbook_" + p.MarketSymbol + "_" + strconv.Itoa(p.Depth), nil
+	case TICKER:
+		return "ticker_" + p.Symbol, nil
+	case TRADE:
+		return "trade_" + p.MarketSymbol, nil
+	case CANDLE:
+		return "candle_" + p.MarketSymbol + "_" + p.Interval, nil
+	case MARKETSUMMARY:
+		return "market_summary_" + p.Symbol, nil
+	}
+
+	return "", fmt.Errorf("unsupported message type: %s", method)
+}
+
+// isPrivate reports whether channel needs an authenticated connection
+func isPrivate(channel string) bool {
+	switch channelKey(channel) {
+	case ORDER, BALANCE, DEPOSIT, EXECUTION, "conditional_order":
+		return true
+	}
+
+	return false
+}
+
+// publicChannels returns the channels which do not need authentication
+func publicChannels(channels []string) []string {
+	var public []string
+	for _, ch := range channels {
+		if !isPrivate(ch) {
+			public = append(public, ch)
+		}
+	}
+
+	return public
+}
+
+// channelKey is the case insensitive lookup key of a channel name
+func channelKey(channel string) string {
+	return strings.ToLower(channel)
+}
+
+// label returns the metrics label of the stream
+func (s *Stream) label() string {
+	if s.Name == "" {
+		return "default"
+	}
+
+	return s.Name
+}
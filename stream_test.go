@@ -0,0 +1,32 @@
+package bittrex
+
+import (
+	"testing"
+)
+
+func TestStreamDispatch(t *testing.T) {
+	s := New("", "").NewStream()
+
+	var got []StreamMessage
+	sub, err := s.Subscribe(func(m StreamMessage) { got = append(got, m) }, "orderbook_btc-usd_25", "ticker_ETH-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.dispatch(ORDERBOOK, encodeMessage(t, `{"marketSymbol":"BTC-USD","depth":25,"sequence":7}`))
+	s.dispatch(ORDERBOOK, encodeMessage(t, `{"marketSymbol":"BTC-USD","depth":500,"sequence":8}`))
+	s.dispatch(TICKER, encodeMessage(t, `{"symbol":"ETH-USD"}`))
+
+	if len(got) != 2 || got[0].Channel != "orderbook_BTC-USD_25" || got[1].Method != TICKER {
+		t.Fatalf("unexpected messages: %+v", got)
+	}
+
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.dispatch(TICKER, encodeMessage(t, `{"symbol":"ETH-USD"}`))
+	if len(got) != 2 {
+		t.Fatalf("message dispatched after Unsubscribe: %+v", got[2])
+	}
+}
@@ -9,3 +9,9 @@ type Ticker struct {
 	BidRate       decimal.Decimal `json:"bidRate"`
 	AskRate       decimal.Decimal `json:"askRate"`
 }
+
+// TickersUpdate is a tickers stream message
+type TickersUpdate struct {
+	Sequence int      `json:"sequence"`
+	Deltas   []Ticker `json:"deltas"`
+}
@@ -2,13 +2,18 @@ package bittrex
 
 import "github.com/shopspring/decimal"
 
-// Trade Used in getmarkethistory
+// Trade is an executed trade of a market, used by GetTrades and SubscribeTrades
 type Trade struct {
-	OrderUUID int64           `json:"Id"`
-	Timestamp jTime           `json:"TimeStamp"`
-	Quantity  decimal.Decimal `json:"Quantity"`
-	Price     decimal.Decimal `json:"Price"`
-	Total     decimal.Decimal `json:"Total"`
-	FillType  string          `json:"FillType"`
-	OrderType string          `json:"OrderType"`
+	ID         string          `json:"id"`
+	ExecutedAt jTime           `json:"executedAt"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Rate       decimal.Decimal `json:"rate"`
+	TakerSide  string          `json:"takerSide"`
+}
+
+// TradeUpdate is a trade stream message
+type TradeUpdate struct {
+	Sequence     int     `json:"sequence"`
+	MarketSymbol string  `json:"marketSymbol"`
+	Deltas       []Trade `json:"deltas"`
 }
@@ -0,0 +1,175 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OrderPollInterval is the REST polling interval of WaitForOrder while its
+// stream is not connected
+var OrderPollInterval = 2 * time.Second
+
+// MaxOrderPollBackoff caps the delay of the polls WaitForOrder retries after
+// transient errors. The delay starts at OrderPollInterval and doubles.
+var MaxOrderPollBackoff = time.Minute
+
+// OrderCondition reports whether an order reached the state waited for
+type OrderCondition func(o Order) bool
+
+// UntilClosed is met when the order is closed, filled or not
+func UntilClosed(o Order) bool {
+	return o.Status == ORDERCLOSED
+}
+
+// UntilFilled is met when the order closed completely filled
+func UntilFilled(o Order) bool {
+	return o.Status == ORDERCLOSED && o.FillQuantity.IsPositive() &&
+		(o.Quantity.IsZero() || o.FillQuantity.GreaterThanOrEqual(o.Quantity))
+}
+
+// UntilPartiallyFilled is met as soon as any quantity of the order is filled
+func UntilPartiallyFilled(o Order) bool {
+	return o.FillQuantity.IsPositive()
+}
+
+// WaitForOrder waits until condition is met for the order with orderID and
+// returns it. It follows the order stream of a dedicated Stream, connected
+// in the background, and polls GetOrder right away and every
+// OrderPollInterval while the stream is not connected. Rate limits, server
+// errors and failed requests are retried with backoff. A closed order which
+// does not meet condition is returned with an error.
+func (b *Bittrex) WaitForOrder(ctx context.Context, orderID string, condition OrderCondition) (Order, error) {
+	s := b.NewStream()
+	s.Name = ORDER
+	s.Authenticate()
+
+	run, stop := context.WithCancel(ctx)
+	defer stop()
+	go s.Run(run)
+
+	return s.WaitForOrder(ctx, orderID, condition)
+}
+
+// WaitForOrder is Bittrex.WaitForOrder on s, which must be authenticated and
+// is kept connected by the caller, usually with Run. Any number of waits may
+// share s.
+func (s *Stream) WaitForOrder(ctx context.Context, orderID string, condition OrderCondition) (Order, error) {
+	var mu sync.Mutex
+	var streamed Order
+	updated := make(chan struct{}, 1)
+
+	sub, err := s.Subscribe(func(msg StreamMessage) {
+		var upd OrderUpdate
+		if err := json.Unmarshal(msg.Data, &upd); err != nil || upd.Delta.ID != orderID {
+			return
+		}
+
+		mu.Lock()
+		applied := MergeOrderUpdate(&streamed, upd)
+		mu.Unlock()
+
+		if applied {
+			select {
+			case updated <- struct{}{}:
+			default:
+			}
+		}
+	}, "order")
+	if err != nil {
+		// polling only
+		s.error(err)
+	}
+	if sub != nil {
+		defer sub.Unsubscribe()
+	}
+
+	// covered is the connection which was subscribed during the last poll,
+	// no poll is needed while it is up
+	var covered <-chan struct{}
+	backoff := time.Duration(0)
+
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return Order{}, ctx.Err()
+
+		case <-updated:
+			mu.Lock()
+			o := streamed
+			mu.Unlock()
+
+			if done, err := orderDone(o, condition); done {
+				// the REST state carries the final fills, commission and
+				// proceeds, unless it lags behind the stream
+				if final, ferr := s.b.GetOrder(orderID); ferr == nil {
+					if fdone, ferr := orderDone(final, condition); fdone {
+						return final, ferr
+					}
+				}
+				return o, err
+			}
+
+		case <-poll.C:
+			conn := s.Done()
+			if sub != nil && conn == covered && s.receiving(sub) {
+				poll.Reset(OrderPollInterval)
+				continue
+			}
+
+			o, err := s.b.GetOrder(orderID)
+			switch {
+			case err == nil:
+				if done, err := orderDone(o, condition); done {
+					return o, err
+				}
+				covered = conn
+				backoff = 0
+				poll.Reset(OrderPollInterval)
+
+			case transient(err):
+				backoff *= 2
+				if backoff < OrderPollInterval {
+					backoff = OrderPollInterval
+				}
+				if backoff > MaxOrderPollBackoff {
+					backoff = MaxOrderPollBackoff
+				}
+				poll.Reset(backoff)
+
+			default:
+				return Order{}, err
+			}
+		}
+	}
+}
+
+// transient reports whether a REST call is worth retrying: no response was
+// received, or the API was rate limited or failing
+func transient(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// orderDone reports whether waiting for o is over
+func orderDone(o Order, condition OrderCondition) (bool, error) {
+	if condition(o) {
+		return true, nil
+	}
+
+	if o.Status == ORDERCLOSED {
+		return true, errors.New("order closed before condition was met")
+	}
+
+	return false, nil
+}
@@ -0,0 +1,45 @@
+package bittrex
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderConditions(t *testing.T) {
+	open := Order{Status: ORDEROPEN, Quantity: decimal.NewFromInt(2), FillQuantity: decimal.NewFromInt(1)}
+	if !UntilPartiallyFilled(open) || UntilFilled(open) || UntilClosed(open) {
+		t.Errorf("wrong conditions for %+v", open)
+	}
+
+	cancelled := open
+	cancelled.Status = ORDERCLOSED
+	if done, err := orderDone(cancelled, UntilFilled); !done || err == nil {
+		t.Error("cancelled order should end the wait with an error")
+	}
+
+	filled := cancelled
+	filled.FillQuantity = filled.Quantity
+	if done, err := orderDone(filled, UntilFilled); !done || err != nil {
+		t.Errorf("filled order: done %v err %v", done, err)
+	}
+}
+
+func TestTransientErrors(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("timeout on reading data from Bittrex API"), true},
+		{&APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{&APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{&APIError{StatusCode: http.StatusNotFound}, false},
+		{&APIError{StatusCode: http.StatusUnauthorized}, false},
+	} {
+		if got := transient(c.err); got != c.want {
+			t.Errorf("transient(%v) = %v", c.err, got)
+		}
+	}
+}
@@ -1,18 +1,15 @@
 package bittrex
 
 import (
-	"bytes"
-	"compress/zlib"
+	"context"
 	"crypto/hmac"
 	"crypto/sha512"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"strconv"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -77,288 +74,284 @@ func (b *Bittrex) Authentication(c *signalr.Client) error {
 	return nil
 }
 
-// SubscribeTickerUpdates subscribes for updates of the market.
-func (b *Bittrex) SubscribeTickerUpdates(market string, ticker chan<- Ticker) error {
-	const timeout = 5 * time.Second
-	client := signalr.NewWebsocketClient()
-
-	var updTime int64
-
-	client.OnClientMethod = func(hub string, method string, messages []json.RawMessage) {
-		if hub != WSHUB {
-			return
-		}
-
-		switch method {
-		case HEARTBEAT, TICKER, TRADE:
-			atomic.StoreInt64(&updTime, time.Now().Unix())
+// SubscribeTickerUpdates subscribes for the ticker updates of markets on a
+// dedicated Stream. Markets rejected by the hub are skipped, when all of them
+// are rejected it returns a *SubscribeError.
+// It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeTickerUpdates(ctx context.Context, ticker chan<- Ticker, markets ...string) error {
+	return b.ownedStream(TICKER).SubscribeTickerUpdates(ctx, ticker, markets...)
+}
 
-		default:
-			fmt.Printf("unsupported message type: %s\n", method)
-		}
+// SubscribeTickerUpdates is Bittrex.SubscribeTickerUpdates on s
+func (s *Stream) SubscribeTickerUpdates(ctx context.Context, ticker chan<- Ticker, markets ...string) error {
+	return s.subscribe(s.newSink(ctx, TICKER, ticker), marketChannels("ticker_", markets, "")...)
+}
 
-		for _, msg := range messages {
-			dbuf, err := base64.StdEncoding.DecodeString(strings.Trim(string(msg), `"`))
-			if err != nil {
-				fmt.Printf("DecodeString error: %s %s\n", err.Error(), string(msg))
-				continue
-			}
+// SubscribeOrderUpdates subscribes for updates of the account orders on a
+// dedicated Stream. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeOrderUpdates(ctx context.Context, dataCh chan<- OrderUpdate) error {
+	return b.ownedStream(ORDER).SubscribeOrderUpdates(ctx, dataCh)
+}
 
-			r, err := zlib.NewReader(bytes.NewReader(append([]byte{120, 156}, dbuf...)))
-			if err != nil {
-				fmt.Printf("unzip error %s %s \n", err.Error(), string(msg))
-				continue
-			}
-			defer r.Close()
+// SubscribeOrderUpdates is Bittrex.SubscribeOrderUpdates on s, which must be
+// authenticated
+func (s *Stream) SubscribeOrderUpdates(ctx context.Context, dataCh chan<- OrderUpdate) error {
+	return s.subscribe(s.newSink(ctx, ORDER, dataCh), "order")
+}
 
-			var out bytes.Buffer
-			io.Copy(&out, r)
+// SubscribeOrderbookUpdates subscribes for the orderbook updates of markets
+// with depth, one of ORDERBOOKDEPTHS, on a dedicated Stream.
+// Markets rejected by the hub are skipped, when all of them are rejected it
+// returns a *SubscribeError.
+// To stop subscription, cancel ctx. It returns ctx.Err().
+func (b *Bittrex) SubscribeOrderbookUpdates(ctx context.Context, orderbook chan<- OrderBook, depth int, markets ...string) error {
+	return b.ownedStream(ORDERBOOK).SubscribeOrderbookUpdates(ctx, orderbook, depth, markets...)
+}
 
-			fmt.Println(out.String())
+// SubscribeOrderbookUpdates is Bittrex.SubscribeOrderbookUpdates on s
+func (s *Stream) SubscribeOrderbookUpdates(ctx context.Context, orderbook chan<- OrderBook, depth int, markets ...string) error {
+	if !ORDERBOOKDEPTHS[depth] {
+		return fmt.Errorf("wrong orderbook depth: %d", depth)
+	}
 
-			p := Ticker{}
-			json.Unmarshal([]byte(out.String()), &p)
+	return s.subscribe(s.newSink(ctx, ORDERBOOK, orderbook), marketChannels("orderbook_", markets, "_"+strconv.Itoa(depth))...)
+}
 
-			select {
-			case ticker <- p:
-			default:
-				fmt.Printf("ticker send err: %s %d \n", market, len(ticker))
-			}
+// ownedStream returns a Stream owned by the typed subscription made on it.
+// The subscription connects it, authenticated when one of its channels is
+// private, and closes it when it ends. Decoding and authentication errors
+// are reported to the OnStreamError hooks. Its metrics are labelled name.
+func (b *Bittrex) ownedStream(name string) *Stream {
+	s := b.NewStream()
+	s.Name = name
+	s.owned = true
+	s.OnAuth = func(state AuthState, err error) {
+		if state == AuthFailed {
+			s.error(err)
 		}
 	}
 
-	client.OnMessageError = func(err error) {
-		fmt.Printf("ERROR OCCURRED: %s\n", err.Error())
-	}
-
-	err := doAsyncTimeout(
-		func() error {
-			return client.Connect("https", WSBASE, []string{WSHUB})
-		}, func(err error) {
-			if err == nil {
-				client.Close()
-			}
-		}, timeout)
-	if err != nil {
-		return err
-	}
+	return s
+}
 
-	defer client.Close()
+// subscribe delivers the messages of channels to out until its context is
+// cancelled or it fails with ErrSlowConsumer.
+func (s *Stream) subscribe(out *sink, channels ...string) error {
+	defer out.close()
 
-	_, err = client.CallHub(WSHUB, "Subscribe", []interface{}{"heartbeat", "ticker_" + market, "trade_" + market})
+	detach, err := s.attach(out, channels...)
 	if err != nil {
 		return err
 	}
+	defer detach()
 
-	tick := time.NewTicker(1 * time.Minute)
+	return s.wait(out)
+}
 
-	for {
-		select {
-		case <-client.DisconnectedChannel:
-			return errors.New("client.DisconnectedChannel")
-		case <-tick.C:
-			if time.Now().Unix()-atomic.LoadInt64(&updTime) > 60 {
-				return errors.New("ticker messages timeout")
-			}
+// attach subscribes out to channels and returns the function ending the
+// subscription. An owned stream is connected, a shared one reports the
+// rejected channels to OnError.
+func (s *Stream) attach(out *sink, channels ...string) (func(), error) {
+	if !s.owned {
+		sub, err := s.Subscribe(out.handler(), channels...)
+		if sub == nil {
+			return nil, err
 		}
+		if err != nil {
+			s.error(err)
+		}
+
+		return func() { sub.Unsubscribe() }, nil
 	}
-}
 
-// SubscribeOrderUpdates func
-func (b *Bittrex) SubscribeOrderUpdates(dataCh chan<- OrderUpdate) error {
-	const timeout = 15 * time.Second
-	client := signalr.NewWebsocketClient()
+	if len(publicChannels(channels)) < len(channels) {
+		s.Authenticate()
+	}
 
-	client.OnClientMethod = func(hub string, method string, messages []json.RawMessage) {
+	if _, err := s.Subscribe(out.handler(), channels...); err != nil {
+		return nil, err
+	}
 
-		switch method {
-		case ORDER:
-		case HEARTBEAT:
-			//fmt.Printf("HEARTBEAT\n")
-		case AUTHEXPIRED:
-			//fmt.Printf("AUTHEXPIRED\n")
-		default:
-			//handle unsupported type
-			fmt.Printf("unsupported message type: %s\n", method)
-			return
+	if err := s.Connect(); err != nil {
+		if _, cerr := s.connected(); cerr != nil {
+			return nil, err
 		}
+		// some channels were rejected, the others are subscribed
+		s.error(err)
+	}
 
-		for _, msg := range messages {
+	return s.Close, nil
+}
 
-			dbuf, err := base64.StdEncoding.DecodeString(strings.Trim(string(msg), `"`))
-			if err != nil {
-				fmt.Printf("DecodeString error: %s %s\n", err.Error(), string(msg))
-				continue
-			}
+// wait blocks until the context of out is cancelled, out fails with
+// ErrSlowConsumer or, for an owned stream, the connection is lost
+func (s *Stream) wait(out *sink) error {
+	var done <-chan struct{}
+	if s.owned {
+		done = s.Done()
+	}
 
-			r, err := zlib.NewReader(bytes.NewReader(append([]byte{120, 156}, dbuf...)))
-			if err != nil {
-				fmt.Printf("unzip error %s %s \n", err.Error(), string(msg))
-				continue
-			}
-			defer r.Close()
+	select {
+	case <-out.ctx.Done():
+		return out.ctx.Err()
+	case <-done:
+		return s.Err()
+	case err := <-out.failed:
+		return err
+	}
+}
 
-			var out bytes.Buffer
-			io.Copy(&out, r)
+// marketChannels returns the channel of every market, made of prefix, the
+// upper case market symbol and suffix
+func marketChannels(prefix string, markets []string, suffix string) []string {
+	channels := make([]string, len(markets))
+	for i, m := range markets {
+		channels[i] = prefix + strings.ToUpper(m) + suffix
+	}
 
-			p := OrderUpdate{}
+	return channels
+}
 
-			switch method {
-			case ORDER:
-				json.Unmarshal([]byte(out.String()), &p)
-			default:
-				//handle unsupported type
-				//fmt.Printf("unsupported message type: %v", p.Method)
-			}
+// SubscribeTrades subscribes for the trades of markets on a dedicated Stream.
+// It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeTrades(ctx context.Context, trades chan<- TradeUpdate, markets ...string) error {
+	return b.ownedStream(TRADE).SubscribeTrades(ctx, trades, markets...)
+}
 
-			select {
-			case dataCh <- p:
-			default:
-				fmt.Printf("missed message: %v", p)
-			}
-		}
-	}
+// SubscribeTrades is Bittrex.SubscribeTrades on s
+func (s *Stream) SubscribeTrades(ctx context.Context, trades chan<- TradeUpdate, markets ...string) error {
+	return s.subscribe(s.newSink(ctx, TRADE, trades), marketChannels("trade_", markets, "")...)
+}
 
-	client.OnMessageError = func(err error) {
-		fmt.Printf("ERROR OCCURRED: %s\n", err.Error())
-	}
+// SubscribeCandles subscribes for the candles of market with interval, one of
+// CANDLEINTERVALS, on a dedicated Stream. If seed is set the recent candles
+// from GetCandles are sent first, followed by the stream updates received
+// meanwhile. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeCandles(ctx context.Context, market, interval string, candles chan<- CandleUpdate, seed bool) error {
+	return b.ownedStream(CANDLE).SubscribeCandles(ctx, market, interval, candles, seed)
+}
 
-	err := doAsyncTimeout(
-		func() error {
-			return client.Connect("https", WSBASE, []string{WSHUB})
-		}, func(err error) {
-			if err == nil {
-				client.Close()
-			}
-		}, timeout)
-	if err != nil {
-		return err
+// SubscribeCandles is Bittrex.SubscribeCandles on s
+func (s *Stream) SubscribeCandles(ctx context.Context, market, interval string, candles chan<- CandleUpdate, seed bool) error {
+	if !CANDLEINTERVALS[interval] {
+		return errors.New("wrong candle interval: " + interval)
 	}
 
-	defer client.Close()
+	market = strings.ToUpper(market)
 
-	err = b.Authentication(client)
-	if err != nil {
-		return err
+	out := s.newSink(ctx, CANDLE, candles)
+	defer out.close()
+
+	if seed {
+		out.hold()
 	}
 
-	_, err = client.CallHub(WSHUB, "Subscribe", []interface{}{"heartbeat", "order"})
+	detach, err := s.attach(out, "candle_"+market+"_"+interval)
 	if err != nil {
 		return err
 	}
+	defer detach()
 
-	ticker := time.NewTicker(5 * time.Minute)
-
-	for {
-		<-ticker.C
-
-		err := b.Authentication(client)
+	if seed {
+		recent, err := s.b.GetCandles(market, interval)
 		if err != nil {
-			fmt.Printf("authentication error: %s\n", err)
 			return err
 		}
-	}
-}
 
-// SubscribeOrderbookUpdates subscribes for updates of the market.
-// Updates will be sent to dataCh.
-// To stop subscription, send to, or close 'stop'.
-func (b *Bittrex) SubscribeOrderbookUpdates(market string, orderbook chan<- OrderBook, stop chan bool) error {
-	const timeout = 5 * time.Second
-	client := signalr.NewWebsocketClient()
-
-	var updTime time.Time
-
-	client.OnClientMethod = func(hub string, method string, messages []json.RawMessage) {
-		if hub != WSHUB {
-			return
-		}
-
-		switch method {
-		case HEARTBEAT, ORDERBOOK:
-			updTime = time.Now()
-		default:
-			fmt.Printf("unsupported message type: %s %v\n", method, messages)
+		for _, c := range recent {
+			select {
+			case candles <- CandleUpdate{MarketSymbol: market, Interval: interval, Delta: c}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 
-		for _, msg := range messages {
-			dbuf, err := base64.StdEncoding.DecodeString(strings.Trim(string(msg), `"`))
-			if err != nil {
-				fmt.Printf("DecodeString error: %s %s\n", err.Error(), string(msg))
-				continue
-			}
+		out.release()
+	}
 
-			r, err := zlib.NewReader(bytes.NewReader(append([]byte{120, 156}, dbuf...)))
-			if err != nil {
-				fmt.Printf("unzip error %s %s \n", err.Error(), string(msg))
-				continue
-			}
-			defer r.Close()
+	return s.wait(out)
+}
 
-			var out bytes.Buffer
-			io.Copy(&out, r)
+// SubscribeBalanceUpdates subscribes for updates of the account balances on
+// a dedicated Stream. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeBalanceUpdates(ctx context.Context, dataCh chan<- BalanceUpdate) error {
+	return b.ownedStream(BALANCE).SubscribeBalanceUpdates(ctx, dataCh)
+}
 
-			p := OrderBook{}
+// SubscribeBalanceUpdates is Bittrex.SubscribeBalanceUpdates on s, which
+// must be authenticated
+func (s *Stream) SubscribeBalanceUpdates(ctx context.Context, dataCh chan<- BalanceUpdate) error {
+	return s.subscribe(s.newSink(ctx, BALANCE, dataCh), "balance")
+}
 
-			switch method {
-			case ORDER:
-				json.Unmarshal([]byte(out.String()), &p)
-			default:
+// SubscribeDepositUpdates subscribes for updates of the account deposits on
+// a dedicated Stream. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeDepositUpdates(ctx context.Context, dataCh chan<- DepositUpdate) error {
+	return b.ownedStream(DEPOSIT).SubscribeDepositUpdates(ctx, dataCh)
+}
 
-				//handle unsupported type
-				//fmt.Printf("%s\n", out.String())
-			}
+// SubscribeDepositUpdates is Bittrex.SubscribeDepositUpdates on s, which
+// must be authenticated
+func (s *Stream) SubscribeDepositUpdates(ctx context.Context, dataCh chan<- DepositUpdate) error {
+	return s.subscribe(s.newSink(ctx, DEPOSIT, dataCh), "deposit")
+}
 
-			err = json.Unmarshal([]byte(out.String()), &p)
-			if err != nil {
-				fmt.Printf("orderbook Unmarshal err: %s %s\n", err.Error(), market)
-			}
+// SubscribeExecutionUpdates subscribes for the executions of the account
+// orders on a dedicated Stream. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeExecutionUpdates(ctx context.Context, dataCh chan<- ExecutionUpdate) error {
+	return b.ownedStream(EXECUTION).SubscribeExecutionUpdates(ctx, dataCh)
+}
 
-			select {
-			case orderbook <- p:
-			default:
-				fmt.Printf("orderbook send err: %s %d  \n", market, len(orderbook))
-			}
+// SubscribeExecutionUpdates is Bittrex.SubscribeExecutionUpdates on s, which
+// must be authenticated
+func (s *Stream) SubscribeExecutionUpdates(ctx context.Context, dataCh chan<- ExecutionUpdate) error {
+	return s.subscribe(s.newSink(ctx, EXECUTION, dataCh), "execution")
+}
 
-		}
-	}
+// SubscribeConditionalOrderUpdates subscribes for updates of the account
+// conditional orders on a dedicated Stream.
+// It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeConditionalOrderUpdates(ctx context.Context, dataCh chan<- ConditionalOrderUpdate) error {
+	return b.ownedStream(CONDITIONALORDER).SubscribeConditionalOrderUpdates(ctx, dataCh)
+}
 
-	client.OnMessageError = func(err error) {
-		fmt.Printf("ERROR OCCURRED: %s\n", err.Error())
-	}
+// SubscribeConditionalOrderUpdates is Bittrex.SubscribeConditionalOrderUpdates
+// on s, which must be authenticated
+func (s *Stream) SubscribeConditionalOrderUpdates(ctx context.Context, dataCh chan<- ConditionalOrderUpdate) error {
+	return s.subscribe(s.newSink(ctx, CONDITIONALORDER, dataCh), "conditional_order")
+}
 
-	err := doAsyncTimeout(
-		func() error {
-			return client.Connect("https", WSBASE, []string{WSHUB})
-		}, func(err error) {
-			if err == nil {
-				client.Close()
-			}
-		}, timeout)
-	if err != nil {
-		return err
-	}
+// SubscribeMarketSummaries subscribes for the summaries of all markets on a
+// dedicated Stream. Each update carries the summaries which changed since the
+// previous one. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeMarketSummaries(ctx context.Context, dataCh chan<- MarketSummariesUpdate) error {
+	return b.ownedStream(MARKETSUMMARIES).SubscribeMarketSummaries(ctx, dataCh)
+}
 
-	defer client.Close()
+// SubscribeMarketSummaries is Bittrex.SubscribeMarketSummaries on s
+func (s *Stream) SubscribeMarketSummaries(ctx context.Context, dataCh chan<- MarketSummariesUpdate) error {
+	return s.subscribe(s.newSink(ctx, MARKETSUMMARIES, dataCh), "market_summaries")
+}
 
-	_, err = client.CallHub(WSHUB, "Subscribe", []interface{}{"heartbeat", "orderbook_" + market + "_25"})
-	if err != nil {
-		return err
-	}
+// SubscribeMarketSummary subscribes for the summaries of markets on a
+// dedicated Stream. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeMarketSummary(ctx context.Context, dataCh chan<- MarketSummary, markets ...string) error {
+	return b.ownedStream(MARKETSUMMARY).SubscribeMarketSummary(ctx, dataCh, markets...)
+}
 
-	tick := time.NewTicker(1 * time.Minute)
+// SubscribeMarketSummary is Bittrex.SubscribeMarketSummary on s
+func (s *Stream) SubscribeMarketSummary(ctx context.Context, dataCh chan<- MarketSummary, markets ...string) error {
+	return s.subscribe(s.newSink(ctx, MARKETSUMMARY, dataCh), marketChannels("market_summary_", markets, "")...)
+}
 
-	for {
-		select {
-		case <-client.DisconnectedChannel:
-			return errors.New("client.DisconnectedChannel")
-		case <-stop:
-			return errors.New("StopChannel")
-		case <-tick.C:
-			if time.Now().Sub(updTime) > time.Minute {
-				return errors.New("orderook messages timeout")
-			}
-		}
+// SubscribeTickers subscribes for the tickers of all markets on a dedicated
+// Stream. Each update carries the tickers which changed since the previous
+// one. It returns ctx.Err() when ctx is cancelled.
+func (b *Bittrex) SubscribeTickers(ctx context.Context, dataCh chan<- TickersUpdate) error {
+	return b.ownedStream(TICKERS).SubscribeTickers(ctx, dataCh)
+}
 
-	}
+// SubscribeTickers is Bittrex.SubscribeTickers on s
+func (s *Stream) SubscribeTickers(ctx context.Context, dataCh chan<- TickersUpdate) error {
+	return s.subscribe(s.newSink(ctx, TICKERS, dataCh), "tickers")
 }